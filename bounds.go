@@ -0,0 +1,689 @@
+package semver
+
+import (
+	"math"
+	"sort"
+	"strings"
+)
+
+// bound is one end of the interval accepted by a constraint. A nil version
+// means the interval is unbounded on that side.
+type bound struct {
+	version   *Version
+	inclusive bool
+}
+
+// interval is a contiguous range of versions.
+//
+// Intervals are computed from the numeric rules of the constraint operators
+// only, so they are an approximation when prereleases are involved (e.g. the
+// upper bound of ^1.2.3 is <2.0.0, which on its own would accept 2.0.0-alpha).
+type interval struct {
+	lower, upper bound
+}
+
+// anyInterval accepts every version.
+var anyInterval = interval{}
+
+// emptyInterval accepts no version.
+var emptyInterval = interval{
+	lower: bound{version: boundVersion([]uint64{0}, "")},
+	upper: bound{version: boundVersion([]uint64{0}, "")},
+}
+
+// boundVersion creates the version used as an interval bound. Parts are padded
+// with zeros to at least three so bounds render in a familiar form.
+func boundVersion(parts []uint64, pre string) *Version {
+	n := len(parts)
+	if n < 3 {
+		n = 3
+	}
+
+	v := &Version{
+		parts: make([]uint64, n),
+		pre:   pre,
+	}
+	copy(v.parts, parts)
+	v.updateOriginal()
+
+	return v
+}
+
+// nextBoundVersion returns the first n parts of v with the last one increased
+// by one, used as the exclusive upper bound of ~ and ^. A part already at the
+// max uint64 value carries into the previous one, so 1.max gives 2.0. nil is
+// returned when all of them are at the max value, as there is no bound.
+func nextBoundVersion(v *Version, n int) *Version {
+	parts := make([]uint64, n)
+	for i := range parts {
+		parts[i] = v.Part(i + 1)
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		if parts[i] != math.MaxUint64 {
+			parts[i]++
+			return boundVersion(parts, "")
+		}
+		parts[i] = 0
+	}
+
+	return nil
+}
+
+// isEmpty reports whether no version fits in the interval.
+func (r interval) isEmpty() bool {
+	if r.lower.version == nil || r.upper.version == nil {
+		return false
+	}
+
+	switch r.lower.version.Compare(r.upper.version) {
+	case 1:
+		return true
+	case 0:
+		return !r.lower.inclusive || !r.upper.inclusive
+	default:
+		return false
+	}
+}
+
+// isExact reports whether the interval contains a single version.
+func (r interval) isExact() bool {
+	return r.lower.version != nil && r.upper.version != nil &&
+		r.lower.inclusive && r.upper.inclusive &&
+		r.lower.version.Equal(r.upper.version)
+}
+
+// intersect returns the interval of versions in both r and o.
+func (r interval) intersect(o interval) interval {
+	res := r
+
+	if o.lower.version != nil {
+		if res.lower.version == nil {
+			res.lower = o.lower
+		} else if d := o.lower.version.Compare(res.lower.version); d > 0 || (d == 0 && !o.lower.inclusive) {
+			res.lower = o.lower
+		}
+	}
+
+	if o.upper.version != nil {
+		if res.upper.version == nil {
+			res.upper = o.upper
+		} else if d := o.upper.version.Compare(res.upper.version); d < 0 || (d == 0 && !o.upper.inclusive) {
+			res.upper = o.upper
+		}
+	}
+
+	return res
+}
+
+// interval returns the range of versions accepted by the constraint. ok is
+// false when the accepted versions are not contiguous, which is the case for
+// the != operator.
+func (c *constraint) interval() (r interval, ok bool) {
+	switch c.origfunc {
+	case "!=":
+		return interval{}, false
+	case "=":
+		v := boundVersion(c.con.parts, c.con.pre)
+		if c.anyPrerelease {
+			// 0 is the lowest possible prerelease
+			return interval{lower: bound{boundVersion(c.con.parts, "0"), true}, upper: bound{v, true}}, true
+		}
+		return interval{lower: bound{v, true}, upper: bound{v, true}}, true
+	case ">", ">=", "=>", "<", "<=", "=<":
+		if c.dirtyPart > 0 { // always fails, see constraintGreaterThan etc.
+			return emptyInterval, true
+		}
+
+		v := boundVersion(c.con.parts, c.con.pre)
+		switch c.origfunc {
+		case ">":
+			return interval{lower: bound{v, false}}, true
+		case ">=", "=>":
+			return interval{lower: bound{v, true}}, true
+		case "<":
+			return interval{upper: bound{v, false}}, true
+		default:
+			return interval{upper: bound{v, true}}, true
+		}
+	case "^":
+		leftZeroPartNumber := c.con.leftZeroPartNumber()
+		shouldEqualNumber := leftZeroPartNumber + 1
+		if leftZeroPartNumber == c.con.PartsNumber() { // all zero
+			shouldEqualNumber = leftZeroPartNumber
+		}
+
+		return interval{
+			lower: bound{boundVersion(c.con.parts, c.con.pre), true},
+			upper: bound{nextBoundVersion(c.con, shouldEqualNumber), false},
+		}, true
+	default: // "", "~" and "~>"
+		if c.dirtyPart == 1 || (c.origfunc == "~" && c.con.isZero()) {
+			return anyInterval, true
+		}
+
+		// see constraintTilde for the parts that have to be kept
+		n := c.con.PartsNumber()
+		switch {
+		case n <= 1:
+			n = 1
+		case n <= 3:
+			n = 2
+		default:
+			n--
+		}
+
+		return interval{
+			lower: bound{boundVersion(c.con.parts, c.con.pre), true},
+			upper: bound{nextBoundVersion(c.con, n), false},
+		}, true
+	}
+}
+
+// andInterval returns the interval accepted by an AND group together with the
+// versions excluded from it by != comparators.
+func andInterval(group []*constraint) (r interval, excluded []*constraint) {
+	r = anyInterval
+	for _, c := range group {
+		ci, ok := c.interval()
+		if !ok {
+			excluded = append(excluded, c)
+			continue
+		}
+		r = r.intersect(ci)
+	}
+
+	return r, excluded
+}
+
+// Humanize describes the constraints in plain English, for example ^1.2.3 is
+// described as "version 1.2.3 or higher, but less than 2.0.0".
+func (cs Constraints) Humanize() string {
+	buf := make([]string, len(cs.constraints))
+
+	for k, group := range cs.constraints {
+		r, excluded := andInterval(group)
+
+		s := r.humanize()
+		if len(excluded) > 0 && !r.isEmpty() {
+			ex := make([]string, len(excluded))
+			for i, c := range excluded {
+				ex[i] = c.orig
+			}
+			s += ", excluding " + strings.Join(ex, ", ")
+		}
+		buf[k] = s
+	}
+
+	return strings.Join(buf, "; or ")
+}
+
+func (r interval) humanize() string {
+	switch {
+	case r.isEmpty():
+		return "no version"
+	case r.isExact():
+		return "exactly version " + r.lower.version.String()
+	case r.lower.version == nil && r.upper.version == nil:
+		return "any version"
+	}
+
+	var lower, upper string
+
+	if l := r.lower.version; l != nil {
+		if r.lower.inclusive {
+			lower = "version " + l.String() + " or higher"
+		} else {
+			lower = "versions higher than " + l.String()
+		}
+	}
+
+	if u := r.upper.version; u != nil {
+		switch {
+		case lower != "" && r.upper.inclusive:
+			upper = "but no higher than " + u.String()
+		case lower != "":
+			upper = "but less than " + u.String()
+		case r.upper.inclusive:
+			upper = "version " + u.String() + " or lower"
+		default:
+			upper = "versions less than " + u.String()
+		}
+	}
+
+	switch {
+	case lower == "":
+		return upper
+	case upper == "":
+		return lower
+	default:
+		return lower + ", " + upper
+	}
+}
+
+// IntervalNotation renders the constraints in mathematical interval notation,
+// e.g. [1.2.3, 2.0.0) for ^1.2.3. Inclusive bounds use [ and ], exclusive ones
+// ( and ), missing bounds are -∞ and +∞ and OR groups are joined with ∪.
+//
+// Versions excluded with != are subtracted from the interval, so
+// >=1.0.0, !=1.2.3 is [1.0.0, +∞) \ {1.2.3}. An exclusion of any prerelease
+// keeps its wildcard, so !=1.2.3-* removes {1.2.3-*}, which stands for 1.2.3
+// and all its prereleases, while an exclusion with an x removes the range it
+// covers, so >=1.0.0, !=1.x is [1.0.0, +∞) \ [1.0.0, 2.0.0). A group nothing
+// can satisfy is ∅.
+func (cs Constraints) IntervalNotation() string {
+	buf := make([]string, len(cs.constraints))
+
+	for k, group := range cs.constraints {
+		r, excluded := andInterval(group)
+
+		s := r.notation()
+		if len(excluded) > 0 && !r.isEmpty() {
+			var ex, ranges []string
+			for _, c := range excluded {
+				if c.dirtyPart > 0 && !c.anyPrerelease {
+					ranges = append(ranges, c.wildcardInterval().notation())
+					continue
+				}
+
+				e := boundVersion(c.con.parts, c.con.pre).String()
+				if c.anyPrerelease {
+					e += "-*"
+				}
+				ex = append(ex, e)
+			}
+
+			if len(ex) > 0 {
+				s += " \\ {" + strings.Join(ex, ", ") + "}"
+			}
+			for _, e := range ranges {
+				s += " \\ " + e
+			}
+		}
+		buf[k] = s
+	}
+
+	return strings.Join(buf, " ∪ ")
+}
+
+// wildcardInterval returns the range of versions matched by the x of the
+// constraint version, e.g. [1.2.0, 1.3.0) for 1.2.x.
+func (c *constraint) wildcardInterval() interval {
+	if c.dirtyPart <= 1 {
+		return anyInterval
+	}
+
+	parts := make([]uint64, c.dirtyPart-1)
+	for i := range parts {
+		parts[i] = c.con.Part(i + 1)
+	}
+
+	return interval{
+		lower: bound{boundVersion(parts, ""), true},
+		upper: bound{nextBoundVersion(c.con, c.dirtyPart-1), false},
+	}
+}
+
+func (r interval) notation() string {
+	if r.isEmpty() {
+		return "∅"
+	}
+
+	lower, upper := "(-∞", "+∞)"
+
+	if l := r.lower.version; l != nil {
+		if r.lower.inclusive {
+			lower = "[" + l.String()
+		} else {
+			lower = "(" + l.String()
+		}
+	}
+
+	if u := r.upper.version; u != nil {
+		if r.upper.inclusive {
+			upper = u.String() + "]"
+		} else {
+			upper = u.String() + ")"
+		}
+	}
+
+	return lower + ", " + upper
+}
+
+// RecommendedFloor returns the lowest version satisfying the first OR group of
+// the constraints, as an "install at least this" hint. For ^1.2.3 it is 1.2.3.
+//
+// When the lower bound is exclusive the next version is recommended: the next
+// patch for a release (1.2.4 for >1.2.3) and the release for a prerelease
+// (1.2.3 for >1.2.3-beta). Versions excluded by != are skipped, wildcards
+// included: for >=1.0.0, !=1.x it is 2.0.0. nil is returned when nothing can
+// satisfy the group.
+func (cs Constraints) RecommendedFloor() *Version {
+	if len(cs.constraints) == 0 {
+		return nil
+	}
+
+	group := cs.constraints[0]
+	r, _ := andInterval(group)
+	if r.isEmpty() {
+		return nil
+	}
+
+	floor := r.lower.version
+	if floor == nil {
+		floor = boundVersion([]uint64{0}, "")
+	} else if !r.lower.inclusive {
+		floor = floorSuccessor(floor)
+	}
+
+	// Every step moves the floor past the comparator rejecting it, which
+	// then accepts all the following versions, so there is at most one step
+	// for each comparator.
+	for steps := 0; steps <= len(group) && floor != nil && r.contains(floor); steps++ {
+		var rejecting *constraint
+		for _, c := range group {
+			if ok, _ := c.check(floor); !ok {
+				rejecting = c
+				break
+			}
+		}
+
+		switch {
+		case rejecting == nil:
+			return floor
+		case rejecting.origfunc != "!=":
+			floor = floorSuccessor(floor)
+		case rejecting.dirtyPart == 1: // != x excludes everything
+			return nil
+		case rejecting.dirtyPart > 1: // e.g. != 1.2.x, skip to 1.3.0
+			floor = nextBoundVersion(rejecting.con, rejecting.dirtyPart-1)
+		case rejecting.anyPrerelease: // != 1.2.3-*, skip to 1.2.4
+			floor = floorSuccessor(boundVersion(rejecting.con.parts, ""))
+		default:
+			floor = floorSuccessor(floor)
+		}
+	}
+
+	return nil
+}
+
+// floorSuccessor returns the release following v: v without its prerelease,
+// or v with its last part increased. nil is returned if it would overflow.
+func floorSuccessor(v *Version) *Version {
+	next, err := v.IncPartChecked(len(v.parts))
+	if err != nil {
+		return nil
+	}
+
+	return &next
+}
+
+// ClosestMiss returns the version that comes closest to satisfying the
+// constraints among the versions not satisfying them (according to Check), to
+// suggest an alternative when nothing matches, e.g. 2.9.1 for ^3.0.0.
+//
+// Versions within the bounds of an OR group but rejected by != are the
+// closest. Then versions lower than the range of an OR group are preferred
+// over versions higher than it, as they are not affected by breaking changes.
+// Of the lower versions the highest one is the closest, so 2.9.1 is preferred
+// over 2.0.0 for ^3.0.0, and of the higher versions the lowest one. Ties keep
+// the first version.
+//
+// nil is returned if there is no such version.
+func (cs Constraints) ClosestMiss(versions []*Version) *Version {
+	const (
+		within = iota
+		lower
+		higher
+	)
+
+	var closest *Version
+	closestKind := within
+
+	for _, v := range versions {
+		if v == nil || cs.Check(v) {
+			continue
+		}
+
+		for _, group := range cs.constraints {
+			r, _ := andInterval(group)
+			if r.isEmpty() {
+				continue
+			}
+
+			kind := within
+			if l := r.lower.version; l != nil && (v.LessThan(l) || (v.Equal(l) && !r.lower.inclusive)) {
+				kind = lower
+			} else if u := r.upper.version; u != nil && (v.GreaterThan(u) || (v.Equal(u) && !r.upper.inclusive)) {
+				kind = higher
+			}
+
+			closer := closest == nil || kind < closestKind
+			if !closer && kind == closestKind {
+				switch kind {
+				case lower:
+					closer = v.GreaterThan(closest)
+				case higher:
+					closer = v.LessThan(closest)
+				}
+			}
+
+			if closer {
+				closest, closestKind = v, kind
+			}
+		}
+	}
+
+	return closest
+}
+
+// contains reports whether v fits in the interval.
+func (r interval) contains(v *Version) bool {
+	if l := r.lower.version; l != nil {
+		if d := v.Compare(l); d < 0 || (d == 0 && !r.lower.inclusive) {
+			return false
+		}
+	}
+
+	if u := r.upper.version; u != nil {
+		if d := v.Compare(u); d > 0 || (d == 0 && !r.upper.inclusive) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// checkAll reports whether v satisfies every constraint of an AND group.
+func checkAll(group []*constraint, v *Version) bool {
+	for _, c := range group {
+		if ok, _ := c.check(v); !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CoversAll reports whether every version from low to high (both inclusive)
+// satisfies the constraints. It is computed from the bounds of the
+// constraints, OR groups that touch or overlap are combined.
+//
+// OR groups using != are not contiguous and never count as covering a range,
+// so a constraint like >=1.0.0, !=1.2.3 always returns false.
+func (cs Constraints) CoversAll(low, high *Version) bool {
+	var rs []interval
+	for _, group := range cs.constraints {
+		r, excluded := andInterval(group)
+		if len(excluded) == 0 && !r.isEmpty() {
+			rs = append(rs, r)
+		}
+	}
+
+	for _, r := range unionIntervals(rs) {
+		if r.contains(low) && r.contains(high) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compareLower compares two lower bounds, an unbounded one is the lowest.
+func compareLower(a, b bound) int {
+	switch {
+	case a.version == nil && b.version == nil:
+		return 0
+	case a.version == nil:
+		return -1
+	case b.version == nil:
+		return 1
+	}
+
+	if d := a.version.Compare(b.version); d != 0 {
+		return d
+	}
+
+	switch {
+	case a.inclusive == b.inclusive:
+		return 0
+	case a.inclusive:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// compareUpper compares two upper bounds, an unbounded one is the highest.
+func compareUpper(a, b bound) int {
+	switch {
+	case a.version == nil && b.version == nil:
+		return 0
+	case a.version == nil:
+		return 1
+	case b.version == nil:
+		return -1
+	}
+
+	if d := a.version.Compare(b.version); d != 0 {
+		return d
+	}
+
+	switch {
+	case a.inclusive == b.inclusive:
+		return 0
+	case a.inclusive:
+		return 1
+	default:
+		return -1
+	}
+}
+
+// unionIntervals merges overlapping or touching intervals. The result is
+// ordered by the lower bounds.
+func unionIntervals(rs []interval) []interval {
+	if len(rs) == 0 {
+		return nil
+	}
+
+	sorted := append([]interval{}, rs...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return compareLower(sorted[i].lower, sorted[j].lower) < 0
+	})
+
+	res := []interval{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &res[len(res)-1]
+
+		if last.touches(r) {
+			if compareUpper(r.upper, last.upper) > 0 {
+				last.upper = r.upper
+			}
+			continue
+		}
+
+		res = append(res, r)
+	}
+
+	return res
+}
+
+// touches reports whether o, which must not start before r, overlaps r or
+// starts right where r ends.
+func (r interval) touches(o interval) bool {
+	if r.upper.version == nil || o.lower.version == nil {
+		return true
+	}
+
+	switch o.lower.version.Compare(r.upper.version) {
+	case -1:
+		return true
+	case 0:
+		return o.lower.inclusive || r.upper.inclusive
+	default:
+		return false
+	}
+}
+
+// RangesOverlap reports whether some version may satisfy both a and b. It is
+// computed from the bounds of the constraints rather than by enumerating
+// versions, e.g. >=1.0.0 <2.0.0 and >=1.5.0 <3.0.0 overlap while <1.0.0 and
+// >=2.0.0 do not.
+//
+// The != operator is handled conservatively: the ranges are assumed to overlap
+// unless the != excludes the only version they have in common.
+func RangesOverlap(a, b *Constraints) bool {
+	for _, ga := range a.constraints {
+		ra, _ := andInterval(ga)
+
+		for _, gb := range b.constraints {
+			rb, _ := andInterval(gb)
+
+			r := ra.intersect(rb)
+			if r.isEmpty() {
+				continue
+			}
+
+			if r.isExact() && !exactOverlap(ga, gb, r.lower.version) {
+				continue
+			}
+
+			return true
+		}
+	}
+
+	return false
+}
+
+// exactOverlap reports whether the version v, the only one left by the bounds
+// of the groups a and b, satisfies both of them. The bound has no metadata,
+// so the versions of the exact comparators are tried as well, as =1.2.3+meta
+// only accepts 1.2.3+meta.
+func exactOverlap(a, b []*constraint, v *Version) bool {
+	candidates := []*Version{v}
+	for _, group := range [][]*constraint{a, b} {
+		for _, c := range group {
+			if c.origfunc == "=" && c.con.metadata != "" {
+				candidates = append(candidates, c.con)
+			}
+		}
+	}
+
+	for _, v := range candidates {
+		if checkAll(a, v) && checkAll(b, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// AreDisjoint reports whether no version can satisfy both a and b, i.e. the
+// constraints contradict each other, e.g. ^1.2 and ^2.
+//
+// It is the inverse of RangesOverlap and stays conservative: when != is
+// involved the constraints are only reported as disjoint if their bounds
+// already are, or if the != excludes the only version they have in common.
+func AreDisjoint(a, b *Constraints) bool {
+	return !RangesOverlap(a, b)
+}
@@ -0,0 +1,313 @@
+package semver
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ImSingee/tt"
+)
+
+func TestConstraintInterval(t *testing.T) {
+	tests := []struct {
+		constraint string
+		lower      string
+		lowerInc   bool
+		upper      string
+		upperInc   bool
+	}{
+		{"*", "", false, "", false},
+		{"~0.0.0", "", false, "", false},
+		{"=1.2", "1.2.0", true, "1.2.0", true},
+		{">1.2.3", "1.2.3", false, "", false},
+		{">=1.2.3-beta", "1.2.3-beta", true, "", false},
+		{"<2", "", false, "2.0.0", false},
+		{"<=2", "", false, "2.0.0", true},
+		{"^1.2.3", "1.2.3", true, "2.0.0", false},
+		{"^0.2.3", "0.2.3", true, "0.3.0", false},
+		{"^0.0.3", "0.0.3", true, "0.0.4", false},
+		{"^0.0", "0.0.0", true, "0.1.0", false},
+		{"^0", "0.0.0", true, "1.0.0", false},
+		{"~1", "1.0.0", true, "2.0.0", false},
+		{"~1.2", "1.2.0", true, "1.3.0", false},
+		{"~1.2.3", "1.2.3", true, "1.3.0", false},
+		{"~1.2.3.4", "1.2.3.4", true, "1.2.4", false},
+		{"1.2.x", "1.2.0", true, "1.3.0", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := parseConstraint(tc.constraint)
+			tt.AssertIsNotError(t, err)
+
+			r, ok := c.interval()
+			tt.AssertTrue(t, ok)
+
+			if tc.lower == "" {
+				tt.AssertIsNil(t, r.lower.version)
+			} else {
+				tt.AssertEqual(t, tc.lower, r.lower.version.String())
+				tt.AssertEqual(t, tc.lowerInc, r.lower.inclusive)
+			}
+
+			if tc.upper == "" {
+				tt.AssertIsNil(t, r.upper.version)
+			} else {
+				tt.AssertEqual(t, tc.upper, r.upper.version.String())
+				tt.AssertEqual(t, tc.upperInc, r.upper.inclusive)
+			}
+		})
+	}
+
+	t.Run("!=", func(t *testing.T) {
+		c, err := parseConstraint("!=1.2.3")
+		tt.AssertIsNotError(t, err)
+
+		_, ok := c.interval()
+		tt.AssertFalse(t, ok)
+	})
+
+	t.Run("wildcard with comparison", func(t *testing.T) {
+		c, err := parseConstraint("<1.x")
+		tt.AssertIsNotError(t, err)
+
+		r, ok := c.interval()
+		tt.AssertTrue(t, ok)
+		tt.AssertTrue(t, r.isEmpty())
+	})
+}
+
+func TestHumanize(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"*", "any version"},
+		{"^1.2.3", "version 1.2.3 or higher, but less than 2.0.0"},
+		{"~1.2.3", "version 1.2.3 or higher, but less than 1.3.0"},
+		{">=1.2.3", "version 1.2.3 or higher"},
+		{">1.2.3", "versions higher than 1.2.3"},
+		{"<2.0.0", "versions less than 2.0.0"},
+		{"<=2.0.0", "version 2.0.0 or lower"},
+		{">1, <=2", "versions higher than 1.0.0, but no higher than 2.0.0"},
+		{"=1.2.3", "exactly version 1.2.3"},
+		{"1.0 - 2.0", "version 1.0.0 or higher, but no higher than 2.0.0"},
+		{">=1.0.0, <2.0.0, !=1.2.3", "version 1.0.0 or higher, but less than 2.0.0, excluding 1.2.3"},
+		{"^1 || ^3", "version 1.0.0 or higher, but less than 2.0.0; or version 3.0.0 or higher, but less than 4.0.0"},
+		{">2, <1", "no version"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := NewConstraint(tc.constraint)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.want, c.Humanize())
+		})
+	}
+}
+
+func TestIntervalNotation(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"*", "(-∞, +∞)"},
+		{"^1.2.3", "[1.2.3, 2.0.0)"},
+		{"~1.2", "[1.2.0, 1.3.0)"},
+		{">1.2.3", "(1.2.3, +∞)"},
+		{"<=2", "(-∞, 2.0.0]"},
+		{"=1.2.3", "[1.2.3, 1.2.3]"},
+		{"1.0 - 2.0", "[1.0.0, 2.0.0]"},
+		{">=1.0.0, !=1.2.3, !=1.3", "[1.0.0, +∞) \\ {1.2.3, 1.3.0}"},
+		{">=1.0.0, !=1.2.3-*, !=1.2.4-beta", "[1.0.0, +∞) \\ {1.2.3-*, 1.2.4-beta}"},
+		{">=1.0.0, !=1.x", "[1.0.0, +∞) \\ [1.0.0, 2.0.0)"},
+		{"!=1.2.x", "(-∞, +∞) \\ [1.2.0, 1.3.0)"},
+		{">=1.0.0, !=1.2.3, !=2.x, !=1.3.x", "[1.0.0, +∞) \\ {1.2.3} \\ [2.0.0, 3.0.0) \\ [1.3.0, 1.4.0)"},
+		{"!=x", "(-∞, +∞) \\ (-∞, +∞)"},
+		{"^1 || ^3", "[1.0.0, 2.0.0) ∪ [3.0.0, 4.0.0)"},
+		{">2, <1", "∅"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := NewConstraint(tc.constraint)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.want, c.IntervalNotation())
+		})
+	}
+}
+
+func TestRecommendedFloor(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{"^1.2.3", "1.2.3"},
+		{"~1.2", "1.2.0"},
+		{">=1.2.3, <2", "1.2.3"},
+		{">1.2.3", "1.2.4"},
+		{">=1.2.3, !=1.2.3, !=1.2.4", "1.2.5"},
+		{"<2.0.0", "0.0.0"},
+		{"*", "0.0.0"},
+		{"1.x || ^3", "1.0.0"},
+		{"^3 || 1.x", "3.0.0"},
+		{">=1.2.3-beta", "1.2.3-beta"},
+		{">2, <1", ""},
+		{"=1.2.3, !=1.2.3", ""},
+		{">1.2.3-beta", "1.2.3"},
+		{">1.2.3-beta, !=1.2.3", "1.2.4"},
+		{">=1.0.0, !=1.x", "2.0.0"},
+		{">=1.2.0, !=1.2.x", "1.3.0"},
+		{">=1.0.0 <2.0.0, !=1.0.x", "1.1.0"},
+		{">=1.0.0 <2.0.0, !=1.x", ""},
+		{">=1.2.3, !=1.2.3-*", "1.2.4"},
+		{">=1.0.0, !=x", ""},
+		{">=1.2.3, !=1.2.3, !=1.2.x, !=1.x, !=2.0.0", "2.0.1"},
+		{">18446744073709551615.18446744073709551615.18446744073709551615", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := NewConstraint(tc.constraint)
+			tt.AssertIsNotError(t, err)
+
+			v := c.RecommendedFloor()
+			if tc.want == "" {
+				tt.AssertIsNil(t, v)
+				return
+			}
+
+			tt.AssertIsNotNil(t, v)
+			tt.AssertEqual(t, tc.want, v.String())
+		})
+	}
+}
+
+func TestCoversAll(t *testing.T) {
+	tests := []struct {
+		constraint string
+		low, high  string
+		expected   bool
+	}{
+		{"^1.2.0", "1.2.0", "1.9.9", true},
+		{"^1.2.0", "1.1.0", "1.9.9", false},
+		{"^1.2.0", "1.2.0", "2.0.0", false},
+		{">=1.0.0, <2.0.0", "1.0.0", "1.9.9", true},
+		{">1.0.0", "1.0.0", "1.9.9", false},
+		{"*", "0.0.1", "100.0.0", true},
+		{"^1 || ^2", "1.5.0", "2.5.0", true},
+		{"^1 || ^3", "1.5.0", "3.5.0", false},
+		{"<=1.0.0 || >1.0.0", "0.1.0", "5.0.0", true},
+		{"<1.0.0 || >1.0.0", "0.1.0", "5.0.0", false},
+		{">=1.0.0, !=1.2.3", "1.0.0", "1.1.0", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint+" covers "+tc.low+" - "+tc.high, func(t *testing.T) {
+			c, err := NewConstraint(tc.constraint)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.expected, c.CoversAll(MustParse(tc.low), MustParse(tc.high)))
+		})
+	}
+}
+
+func TestRangesOverlap(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{">=1.0.0 <2.0.0", ">=1.5.0 <3.0.0", true},
+		{"<1.0.0", ">=2.0.0", false},
+		{"<1.0.0", ">=1.0.0", false},
+		{"<=1.0.0", ">=1.0.0", true},
+		{"^1.2", "~1.4", true},
+		{"^1.2", "^2", false},
+		{"^1.2 || ^3", "^2 || ^3.5", true},
+		{"*", "=4.5.6", true},
+		{"=1.2.3", "=1.2.3", true},
+		{"=1.2.3", "=1.2.4", false},
+		{"=1.2.3+meta", "=1.2.3+meta", true},
+		{"=1.2.3+meta", "=1.2.3", true},
+		{"=1.2.3+meta", "<=1.2.3", true},
+		{"=1.2.3+a", "=1.2.3+b", false},
+		{"=1.2.3+meta", "!=1.2.3", false},
+		{"=1.2.3", "!=1.2.3", false},
+		{"<=1.2.3", ">=1.2.3, !=1.2.3", false},
+		{">=1.0.0, !=1.2.3", "<2.0.0", true},
+		{">=1.0.0 <2.0.0", "!=1.5.0", true},
+		{"<1.x", "*", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.a+" and "+tc.b, func(t *testing.T) {
+			a, err := NewConstraint(tc.a)
+			tt.AssertIsNotError(t, err)
+			b, err := NewConstraint(tc.b)
+			tt.AssertIsNotError(t, err)
+
+			tt.AssertEqual(t, tc.expected, RangesOverlap(a, b))
+			tt.AssertEqual(t, tc.expected, RangesOverlap(b, a))
+		})
+	}
+}
+
+func TestAreDisjoint(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"^1.2", "^2", true},
+		{"<1.0.0", ">=1.0.0", true},
+		{"^1.2", "~1.4", false},
+		{"^1.2 || ^3", "^2 || ^3.5", false},
+		{"^1.2 || ^4", "^2 || ^3.5", true},
+		{"=1.2.3", "!=1.2.3", true},
+		{">=1.0.0, !=1.2.3", "<2.0.0", false},
+		{">=1.0.0, !=1.2.3, !=1.2.4", ">=1.2.3, <=1.2.4", false}, // can't prove
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.a+" and "+tc.b, func(t *testing.T) {
+			a, b := mustNewConstraint(tc.a), mustNewConstraint(tc.b)
+			tt.AssertEqual(t, tc.expected, AreDisjoint(a, b))
+			tt.AssertEqual(t, tc.expected, AreDisjoint(b, a))
+		})
+	}
+}
+
+func TestClosestMiss(t *testing.T) {
+	tests := []struct {
+		constraint string
+		versions   []string
+		expected   string
+	}{
+		{"^3.0.0", []string{"1.0.0", "2.9.1", "2.10.0", "4.0.0"}, "2.10.0"},
+		{"^3.0.0", []string{"2.0.0", "2.9.1"}, "2.9.1"},
+		{"^3.0.0", []string{"2.9.1", "2.0.0"}, "2.9.1"},
+		{"^3.0.0", []string{"5.0.0", "4.9.9", "6.0.0"}, "4.9.9"},
+		{"^3.0.0", []string{"4.2.0", "4.0.1", "5.0.0"}, "4.0.1"},
+		{">=3.0.0", []string{"2.9.9", "3.0.0-rc.1", "1.0.0"}, "3.0.0-rc.1"},
+		{">=1.0.0, !=1.2.3", []string{"0.9.0", "1.2.3"}, "1.2.3"},
+		{"^1.0.0 || ^3.0.0", []string{"2.9.0", "0.9.0", "4.0.0"}, "2.9.0"},
+		{"^1.0.0 || ^3.0.0", []string{"0.1.0", "2.0.0"}, "2.0.0"},
+		{"^3.0.0", []string{"2.9.1", "2.9.1+build"}, "2.9.1"},
+		{"^3.0.0", []string{"3.1.0"}, ""},
+		{"^3.0.0", nil, ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint+" "+strings.Join(tc.versions, " "), func(t *testing.T) {
+			vs := make([]*Version, len(tc.versions))
+			for i, s := range tc.versions {
+				vs[i] = MustParse(s)
+			}
+
+			closest := mustNewConstraint(tc.constraint).ClosestMiss(vs)
+			if tc.expected == "" {
+				tt.AssertIsNil(t, closest)
+			} else {
+				tt.AssertIsNotNil(t, closest)
+				tt.AssertEqual(t, tc.expected, closest.Original())
+			}
+		})
+	}
+}
@@ -1,5 +1,12 @@
 package semver
 
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
 // Collection is a collection of Version instances and implements the sort
 // interface. See the sort package for more details.
 // https://golang.org/pkg/sort/
@@ -22,3 +29,362 @@ func (c Collection) Less(i, j int) bool {
 func (c Collection) Swap(i, j int) {
 	c[i], c[j] = c[j], c[i]
 }
+
+// Sort sorts the versions in place in ascending order according to Compare,
+// so 1.0.0-alpha comes before 1.0.0. The sort is stable: versions comparing
+// equal, such as 1.2+a and 1.2+b, keep their order.
+func Sort(vs []*Version) {
+	sort.Stable(Collection(vs))
+}
+
+// SortDescending sorts the versions in place in descending order according to
+// Compare, the reverse of Sort. The sort is stable: versions comparing equal
+// keep their order.
+func SortDescending(vs []*Version) {
+	sort.Stable(sort.Reverse(Collection(vs)))
+}
+
+// Max returns the highest of the versions according to Compare. Of versions
+// comparing equal, such as 1.2 and 1.2.0, the first one is returned. Nil
+// versions are ignored and nil is returned if there are no others.
+func Max(vs ...*Version) *Version {
+	var max *Version
+	for _, v := range vs {
+		if v != nil && (max == nil || v.GreaterThan(max)) {
+			max = v
+		}
+	}
+
+	return max
+}
+
+// Min returns the lowest of the versions according to Compare. Of versions
+// comparing equal, such as 1.2 and 1.2.0, the first one is returned. Nil
+// versions are ignored and nil is returned if there are no others.
+func Min(vs ...*Version) *Version {
+	var min *Version
+	for _, v := range vs {
+		if v != nil && (min == nil || v.LessThan(min)) {
+			min = v
+		}
+	}
+
+	return min
+}
+
+// IsLatestInMinor reports whether no version in all shares the major and minor
+// version of v while being greater than it.
+//
+// Versions are compared with Compare, so prereleases are taken into account:
+// 1.2.3 is not the latest when 1.2.4-rc.1 is in the list. Nil entries are
+// ignored.
+func IsLatestInMinor(v *Version, all []*Version) bool {
+	for _, o := range all {
+		if o == nil || o.Part(1) != v.Part(1) || o.Minor() != v.Minor() {
+			continue
+		}
+
+		if o.GreaterThan(v) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseResult is the outcome of parsing one input with ParseAll.
+type ParseResult struct {
+	Input   string
+	Version *Version
+	Err     error
+}
+
+// ParseAll parses every input with NewVersion and returns one result per
+// input, in the same order, so bad entries can be reported by their input.
+func ParseAll(inputs []string) []ParseResult {
+	results := make([]ParseResult, len(inputs))
+	for i, s := range inputs {
+		v, err := NewVersion(s)
+		results[i] = ParseResult{
+			Input:   s,
+			Version: v,
+			Err:     err,
+		}
+	}
+
+	return results
+}
+
+// ReleasesOnly returns the versions without a prerelease, keeping their order.
+// Nil entries are dropped.
+func ReleasesOnly(versions []*Version) []*Version {
+	var res []*Version
+	for _, v := range versions {
+		if v != nil && !v.IsPrerelease() {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// PrereleasesOnly returns the versions with a prerelease, keeping their
+// order. Nil entries are dropped.
+func PrereleasesOnly(versions []*Version) []*Version {
+	var res []*Version
+	for _, v := range versions {
+		if v != nil && v.IsPrerelease() {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// IsMonotonic reports whether the versions are strictly increasing according
+// to Compare, e.g. for linting the order of tags in a release history.
+//
+// When they are not, the index of the first version that is not greater than
+// the one before it is returned as well, otherwise the index is -1.
+func IsMonotonic(versions []*Version) (bool, int) {
+	for i := 1; i < len(versions); i++ {
+		if versions[i].Compare(versions[i-1]) <= 0 {
+			return false, i
+		}
+	}
+
+	return true, -1
+}
+
+// GroupByMinor groups the versions by their "major.minor" key, e.g. 1.2.3 and
+// 1.2.4-rc.1 are both in the "1.2" group. Each group is sorted in ascending
+// order, versions that compare equal keep their order. Nil entries are
+// ignored.
+func GroupByMinor(versions []*Version) map[string][]*Version {
+	groups := make(map[string][]*Version)
+	for _, v := range versions {
+		if v == nil {
+			continue
+		}
+
+		key := strconv.FormatUint(v.Part(1), 10) + "." + strconv.FormatUint(v.Minor(), 10)
+		groups[key] = append(groups[key], v)
+	}
+
+	for _, group := range groups {
+		sort.Stable(Collection(group))
+	}
+
+	return groups
+}
+
+// RankUpgrades returns the candidates greater than current, ordered from the
+// smallest to the largest upgrade: patch upgrades first, then minor and then
+// major ones. Upgrades changing the 4th part or later, or only promoting a
+// prerelease of current, are patch upgrades.
+//
+// Within the same kind of upgrade releases come before prereleases and are
+// ordered by version, so for 1.2.3 the order is 1.2.4, 1.2.5, 1.2.6-rc.1,
+// 1.3.0, 2.0.0. Nil candidates are ignored.
+func RankUpgrades(current *Version, candidates []*Version) []*Version {
+	var res []*Version
+	for _, c := range candidates {
+		if c != nil && c.GreaterThan(current) {
+			res = append(res, c)
+		}
+	}
+
+	severity := func(v *Version) int {
+		d := firstDifferentPart(v, current)
+		if d == 0 || d > 3 {
+			return 3
+		}
+		return d
+	}
+
+	sort.SliceStable(res, func(i, j int) bool {
+		if si, sj := severity(res[i]), severity(res[j]); si != sj {
+			return si > sj
+		}
+		if pi, pj := res[i].IsPrerelease(), res[j].IsPrerelease(); pi != pj {
+			return pj
+		}
+		return res[i].LessThan(res[j])
+	})
+
+	return res
+}
+
+// MergeHighestPerMinor merges two version lists, e.g. from registry mirrors,
+// keeping only the highest version of each major.minor line found in either
+// of them. The result is sorted in ascending order and nil entries are
+// ignored.
+//
+// When both lists have versions that compare equal as the highest of a line
+// (e.g. 1.2.3+a and 1.2.3+b), the one first found in a, then b, is returned.
+func MergeHighestPerMinor(a, b []*Version) []*Version {
+	all := make([]*Version, 0, len(a)+len(b))
+	all = append(all, a...)
+	all = append(all, b...)
+
+	var res []*Version
+	for _, group := range GroupByMinor(all) {
+		highest := group[0]
+		for _, v := range group[1:] {
+			if v.GreaterThan(highest) {
+				highest = v
+			}
+		}
+		res = append(res, highest)
+	}
+
+	sort.Sort(Collection(res))
+
+	return res
+}
+
+// ValidatePrereleaseSequence checks that the versions are prereleases of the
+// same version and channel whose trailing numbers increase by one without
+// gaps, like 1.2.0-rc.1, 1.2.0-rc.2 and 1.2.0-rc.3. The sequence may start at
+// any number.
+//
+// The returned error names the offending pair, e.g. "1.2.0-rc.3 does not
+// follow 1.2.0-rc.1: skipped rc.2" for a gap.
+func ValidatePrereleaseSequence(versions []*Version) error {
+	for i, v := range versions {
+		if v == nil {
+			return fmt.Errorf("version %d is nil", i)
+		}
+		if _, ok := v.PrereleaseNumber(); !ok {
+			return fmt.Errorf("%s does not have a prerelease number", v)
+		}
+		if i == 0 {
+			continue
+		}
+
+		prev := versions[i-1]
+		if CompareParts(prev.parts, v.parts) != 0 || prereleaseChannel(prev) != prereleaseChannel(v) {
+			return fmt.Errorf("%s does not follow %s: not the same version and channel", v, prev)
+		}
+
+		p, _ := prev.PrereleaseNumber()
+		n, _ := v.PrereleaseNumber()
+		switch {
+		case n == p:
+			return fmt.Errorf("%s does not follow %s: duplicate", v, prev)
+		case n < p:
+			return fmt.Errorf("%s does not follow %s: lower number", v, prev)
+		case n-p > 1:
+			return fmt.Errorf("%s does not follow %s: skipped %s%d", v, prev, prereleaseChannel(v), p+1)
+		}
+	}
+
+	return nil
+}
+
+// prereleaseChannel returns the prerelease of v without its trailing number,
+// keeping the dot before it, e.g. "rc." for rc.1.
+func prereleaseChannel(v *Version) string {
+	return v.pre[:strings.LastIndex(v.pre, ".")+1]
+}
+
+// Nearest returns the candidate closest to target, e.g. to map a requested
+// version to the closest one available. A candidate equal to target is
+// returned first, otherwise the highest candidate lower than target and the
+// lowest candidate higher than it are found and the nearer one is returned.
+// The distance is the difference of the numeric parts, subtracted with
+// borrowing, so for 1.5.0 the version 1.4.9 is nearer than 1.7.0 and for
+// 2.0.0 the version 1.9.0 is nearer than 1.0.0.
+//
+// If both are at the same distance the lower version is returned, equal
+// candidates resolve to the first one. nil is returned if there are no
+// candidates, nil entries are ignored.
+func Nearest(target *Version, candidates []*Version) *Version {
+	var lower, higher *Version
+
+	for _, c := range candidates {
+		if c == nil {
+			continue
+		}
+
+		switch c.Compare(target) {
+		case 0:
+			return c
+		case -1:
+			if lower == nil || c.GreaterThan(lower) {
+				lower = c
+			}
+		case 1:
+			if higher == nil || c.LessThan(higher) {
+				higher = c
+			}
+		}
+	}
+
+	switch {
+	case lower == nil:
+		return higher
+	case higher == nil:
+		return lower
+	case compareDistance(lower, target, higher) > 0:
+		return higher
+	default:
+		return lower
+	}
+}
+
+// AggregateVersion computes a representative version for a bundle, e.g. a
+// meta-package, from the versions of its components. The strategy is one of:
+//
+//   - "max": the highest component, compared with Compare
+//   - "min": the lowest component, compared with Compare
+//   - "sum-patch": major.minor.sum, where sum is the sum of the patch numbers;
+//     all components must share the same major and minor
+//
+// Nil components are ignored. Nil is returned when there are no components,
+// the strategy is unknown or the components of "sum-patch" do not share a
+// major.minor.
+func AggregateVersion(components []*Version, strategy string) *Version {
+	var res *Version
+	var sum uint64
+
+	for _, c := range components {
+		if c == nil {
+			continue
+		}
+
+		if res == nil {
+			res, sum = c, c.Patch()
+			continue
+		}
+
+		switch strategy {
+		case "max":
+			if c.GreaterThan(res) {
+				res = c
+			}
+		case "min":
+			if c.LessThan(res) {
+				res = c
+			}
+		case "sum-patch":
+			if c.Major() != res.Major() || c.Minor() != res.Minor() {
+				return nil
+			}
+			sum += c.Patch()
+		default:
+			return nil
+		}
+	}
+
+	if res == nil {
+		return nil
+	}
+
+	switch strategy {
+	case "max", "min":
+		return res
+	case "sum-patch":
+		return NewVersionByParts(res.Major(), res.Minor(), sum)
+	}
+
+	return nil
+}
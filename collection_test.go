@@ -44,3 +44,430 @@ func TestCollection(t *testing.T) {
 		t.Error("Sorting Collection failed")
 	}
 }
+
+func TestCollectionShuffled(t *testing.T) {
+	e := []string{"1.0.0-alpha", "1.0.0", "1.2.3.4", "2.0.0"}
+
+	shuffles := [][]string{
+		{"2.0.0", "1.0.0", "1.2.3.4", "1.0.0-alpha"},
+		{"1.0.0", "1.0.0-alpha", "2.0.0", "1.2.3.4"},
+		{"1.2.3.4", "2.0.0", "1.0.0-alpha", "1.0.0"},
+	}
+
+	for _, raw := range shuffles {
+		vs := make([]*Version, len(raw))
+		for i, r := range raw {
+			vs[i] = MustParse(r)
+		}
+
+		sort.Sort(Collection(vs))
+
+		a := make([]string, len(vs))
+		for i, v := range vs {
+			a[i] = v.String()
+		}
+
+		if !reflect.DeepEqual(a, e) {
+			t.Errorf("Sorting %v gave %v, expected %v", raw, a, e)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	raw := []string{"2.0.0", "1.2+a", "1.0.0", "1.2.0+b", "1.0.0-alpha", "1.2+c", "1.0.0-beta"}
+
+	vs := make([]*Version, len(raw))
+	for i, r := range raw {
+		vs[i] = MustParse(r)
+	}
+
+	Sort(vs)
+	e := []string{"1.0.0-alpha", "1.0.0-beta", "1.0.0", "1.2+a", "1.2.0+b", "1.2+c", "2.0.0"}
+	a := make([]string, len(vs))
+	for i, v := range vs {
+		a[i] = v.Original()
+	}
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("Sort gave %v, expected %v", a, e)
+	}
+
+	SortDescending(vs)
+	e = []string{"2.0.0", "1.2+a", "1.2.0+b", "1.2+c", "1.0.0", "1.0.0-beta", "1.0.0-alpha"}
+	for i, v := range vs {
+		a[i] = v.Original()
+	}
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("SortDescending gave %v, expected %v", a, e)
+	}
+}
+
+func TestMaxMin(t *testing.T) {
+	tests := []struct {
+		versions []string
+		max      string
+		min      string
+	}{
+		{[]string{"1.2.3", "2.0.0", "1.0.0-beta", "1.0.0"}, "2.0.0", "1.0.0-beta"},
+		{[]string{"1.2", "1.2.0"}, "1.2", "1.2"},
+		{[]string{"1.2.0", "1.2"}, "1.2.0", "1.2.0"},
+		{[]string{"1.2.0+b", "1.1", "1.2+a", "1.1.0+c"}, "1.2.0+b", "1.1"},
+		{[]string{"v3"}, "v3", "v3"},
+	}
+
+	for _, tc := range tests {
+		vs := make([]*Version, len(tc.versions))
+		for i, r := range tc.versions {
+			vs[i] = MustParse(r)
+		}
+
+		if got := Max(vs...); got.Original() != tc.max {
+			t.Errorf("Max(%v) = %s, expected %s", tc.versions, got.Original(), tc.max)
+		}
+		if got := Min(vs...); got.Original() != tc.min {
+			t.Errorf("Min(%v) = %s, expected %s", tc.versions, got.Original(), tc.min)
+		}
+	}
+
+	if Max() != nil || Min() != nil {
+		t.Error("Max and Min of no versions should be nil")
+	}
+	if Max(nil, nil) != nil || Min(nil) != nil {
+		t.Error("Max and Min of nil versions should be nil")
+	}
+}
+
+func TestIsLatestInMinor(t *testing.T) {
+	all := []*Version{
+		MustParse("1.1.9"),
+		MustParse("1.2.0"),
+		MustParse("1.2.3"),
+		MustParse("1.2.4-rc.1"),
+		nil,
+		MustParse("1.3.0"),
+		MustParse("2.0.0"),
+	}
+
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"1.1.9", true},
+		{"1.2.3", false},
+		{"1.2.4-rc.1", true},
+		{"1.2.4", true},
+		{"1.3", true},
+		{"1.3.0-beta", false},
+		{"2.0.0", true},
+		{"3.0.0", true},
+	}
+
+	for _, tc := range tests {
+		if a := IsLatestInMinor(MustParse(tc.version), all); a != tc.expected {
+			t.Errorf("IsLatestInMinor(%q) expected %t but got %t", tc.version, tc.expected, a)
+		}
+	}
+}
+
+func TestParseAll(t *testing.T) {
+	results := ParseAll([]string{"1.2.3", "foo", "v2.0", ""})
+
+	if len(results) != 4 {
+		t.Fatalf("Expected 4 results but got %d", len(results))
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+		err      error
+	}{
+		{"1.2.3", "1.2.3", nil},
+		{"foo", "", ErrInvalidCharacters},
+		{"v2.0", "2.0", nil},
+		{"", "", ErrEmptyString},
+	}
+
+	for i, tc := range tests {
+		r := results[i]
+		if r.Input != tc.input {
+			t.Errorf("Expected input %q but got %q", tc.input, r.Input)
+		}
+		if r.Err != tc.err {
+			t.Errorf("Expected error %v for %q but got %v", tc.err, tc.input, r.Err)
+		}
+		if tc.err == nil && r.Version.String() != tc.expected {
+			t.Errorf("Expected version %q for %q but got %q", tc.expected, tc.input, r.Version)
+		}
+		if tc.err != nil && r.Version != nil {
+			t.Errorf("Expected no version for %q", tc.input)
+		}
+	}
+}
+
+func TestReleasesOnly(t *testing.T) {
+	vs := []*Version{
+		MustParse("2.0.0-rc.1"),
+		MustParse("1.0.0"),
+		nil,
+		MustParse("1.1.0-beta+meta"),
+		MustParse("1.2.0+meta"),
+		MustParse("0.9"),
+	}
+
+	releases := ReleasesOnly(vs)
+	if !reflect.DeepEqual(releases, []*Version{vs[1], vs[4], vs[5]}) {
+		t.Errorf("ReleasesOnly returned unexpected versions: %v", releases)
+	}
+
+	prereleases := PrereleasesOnly(vs)
+	if !reflect.DeepEqual(prereleases, []*Version{vs[0], vs[3]}) {
+		t.Errorf("PrereleasesOnly returned unexpected versions: %v", prereleases)
+	}
+
+	if ReleasesOnly(nil) != nil || PrereleasesOnly(nil) != nil {
+		t.Error("Expected nil for nil input")
+	}
+}
+
+func TestIsMonotonic(t *testing.T) {
+	tests := []struct {
+		versions []string
+		ok       bool
+		index    int
+	}{
+		{nil, true, -1},
+		{[]string{"1.0.0"}, true, -1},
+		{[]string{"1.0.0-rc.1", "1.0.0", "1.0.1", "1.1", "2.0.0"}, true, -1},
+		{[]string{"1.0.0", "1.2.0", "1.1.0", "2.0.0"}, false, 2},
+		{[]string{"1.0.0", "1.0.0+build", "1.0.1"}, false, 1},
+		{[]string{"1.0.0", "1.0.1", "1.0.1-rc.1"}, false, 2},
+	}
+
+	for _, tc := range tests {
+		vs := make([]*Version, len(tc.versions))
+		for i, s := range tc.versions {
+			vs[i] = MustParse(s)
+		}
+
+		ok, index := IsMonotonic(vs)
+		if ok != tc.ok || index != tc.index {
+			t.Errorf("IsMonotonic(%v): expected (%t, %d) but got (%t, %d)", tc.versions, tc.ok, tc.index, ok, index)
+		}
+	}
+}
+
+func TestGroupByMinor(t *testing.T) {
+	vs := []*Version{
+		MustParse("1.2.4"),
+		MustParse("2.0.0"),
+		nil,
+		MustParse("1.2.3"),
+		MustParse("1.3.0-rc.1"),
+		MustParse("1.2.4-rc.1"),
+		MustParse("v1.2"),
+		MustParse("1"),
+	}
+
+	groups := GroupByMinor(vs)
+
+	expected := map[string][]string{
+		"1.0": {"1"},
+		"1.2": {"1.2", "1.2.3", "1.2.4-rc.1", "1.2.4"},
+		"1.3": {"1.3.0-rc.1"},
+		"2.0": {"2.0.0"},
+	}
+
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups but got %d", len(expected), len(groups))
+	}
+
+	for key, want := range expected {
+		got := make([]string, len(groups[key]))
+		for i, v := range groups[key] {
+			got[i] = v.String()
+		}
+
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Expected group %q to be %v but got %v", key, want, got)
+		}
+	}
+
+	if len(GroupByMinor(nil)) != 0 {
+		t.Error("Expected no groups for nil input")
+	}
+}
+
+func TestRankUpgrades(t *testing.T) {
+	candidates := []*Version{
+		MustParse("2.0.0"),
+		MustParse("1.2.6-rc.1"),
+		MustParse("1.2.2"),
+		nil,
+		MustParse("1.3.0"),
+		MustParse("1.2.5"),
+		MustParse("1.2.3"),
+		MustParse("1.2.4"),
+		MustParse("1.2.3.1"),
+		MustParse("1.4.0-beta"),
+		MustParse("1.3.1"),
+	}
+
+	got := RankUpgrades(MustParse("1.2.3"), candidates)
+
+	expected := []string{"1.2.3.1", "1.2.4", "1.2.5", "1.2.6-rc.1", "1.3.0", "1.3.1", "1.4.0-beta", "2.0.0"}
+	strs := make([]string, len(got))
+	for i, v := range got {
+		strs[i] = v.String()
+	}
+
+	if !reflect.DeepEqual(strs, expected) {
+		t.Errorf("Expected %v but got %v", expected, strs)
+	}
+
+	got = RankUpgrades(MustParse("1.2.3-rc.1"), []*Version{MustParse("1.3.0"), MustParse("1.2.3")})
+	if len(got) != 2 || got[0].String() != "1.2.3" {
+		t.Errorf("Expected the release of the prerelease to be the first upgrade, got %v", got)
+	}
+
+	if RankUpgrades(MustParse("1.2.3"), nil) != nil {
+		t.Error("Expected nil for no candidates")
+	}
+}
+
+func TestMergeHighestPerMinor(t *testing.T) {
+	a := []*Version{
+		MustParse("1.2.3"),
+		MustParse("1.3.0"),
+		nil,
+		MustParse("2.0.1+a"),
+	}
+	b := []*Version{
+		MustParse("1.2.4"),
+		MustParse("1.3.0-rc.1"),
+		MustParse("2.0.1+b"),
+		MustParse("0.9.0"),
+	}
+
+	got := MergeHighestPerMinor(a, b)
+
+	expected := []*Version{b[3], b[0], a[1], a[3]}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v but got %v", expected, got)
+	}
+
+	if MergeHighestPerMinor(nil, nil) != nil {
+		t.Error("Expected nil for no versions")
+	}
+}
+
+func TestValidatePrereleaseSequence(t *testing.T) {
+	tests := []struct {
+		versions []string
+		err      string
+	}{
+		{nil, ""},
+		{[]string{"1.2.0-rc.1"}, ""},
+		{[]string{"1.2.0-rc.1", "1.2.0-rc.2", "1.2.0-rc.3"}, ""},
+		{[]string{"1.2.0-rc.4", "1.2.0-rc.5+build"}, ""},
+		{[]string{"1.2.0-1", "1.2.0-2"}, ""},
+		{[]string{"1.2.0-rc.1", "1.2.0-rc.3"}, "1.2.0-rc.3 does not follow 1.2.0-rc.1: skipped rc.2"},
+		{[]string{"1.2.0-rc.1", "1.2.0-rc.2", "1.2.0-rc.2"}, "1.2.0-rc.2 does not follow 1.2.0-rc.2: duplicate"},
+		{[]string{"1.2.0-rc.2", "1.2.0-rc.1"}, "1.2.0-rc.1 does not follow 1.2.0-rc.2: lower number"},
+		{[]string{"1.2.0-rc.1", "1.2.0-beta.2"}, "1.2.0-beta.2 does not follow 1.2.0-rc.1: not the same version and channel"},
+		{[]string{"1.2.0-rc.1", "1.3.0-rc.2"}, "1.3.0-rc.2 does not follow 1.2.0-rc.1: not the same version and channel"},
+		{[]string{"1.2.0-rc.1", "1.2.0"}, "1.2.0 does not have a prerelease number"},
+		{[]string{"1.2.0-rc"}, "1.2.0-rc does not have a prerelease number"},
+	}
+
+	for _, tc := range tests {
+		vs := make([]*Version, len(tc.versions))
+		for i, s := range tc.versions {
+			vs[i] = MustParse(s)
+		}
+
+		err := ValidatePrereleaseSequence(vs)
+		if tc.err == "" {
+			if err != nil {
+				t.Errorf("Unexpected error %q for %v", err, tc.versions)
+			}
+		} else if err == nil || err.Error() != tc.err {
+			t.Errorf("Expected error %q for %v but got %v", tc.err, tc.versions, err)
+		}
+	}
+}
+
+func TestNearest(t *testing.T) {
+	tests := []struct {
+		target     string
+		candidates []string
+		expected   string
+	}{
+		{"1.5.0", []string{"1.4.9", "1.7.0", "2.0.0"}, "1.4.9"},
+		{"1.5.0", []string{"1.7.0", "1.3.0"}, "1.3.0"},
+		{"1.5.0", []string{"1.6.0", "1.4.0"}, "1.4.0"},
+		{"1.5.0", []string{"2.5.0", "1.9.9"}, "1.9.9"},
+		{"1.5.0", []string{"1.4.0", "1.4.9"}, "1.4.9"},
+		{"2.0.0", []string{"1.0.0", "1.9.0"}, "1.9.0"},
+		{"2.0.0", []string{"2.0.5", "1.9.0"}, "2.0.5"},
+		{"1.2.3", []string{"1.2.3-rc.1", "1.2.3-rc.2"}, "1.2.3-rc.2"},
+		{"1.2.3", []string{"1.2.3-rc.1", "1.2.3"}, "1.2.3"},
+		{"1.2.3-rc.1", []string{"1.2.3", "1.2.3-rc.1"}, "1.2.3-rc.1"},
+		{"1.2.3", []string{"1.2.3.2", "1.2.3.1"}, "1.2.3.1"},
+		{"1.2.3", []string{"1.2.3+a", "1.2.3+b"}, "1.2.3+a"},
+		{"1.2.3", nil, ""},
+	}
+
+	for _, tc := range tests {
+		vs := make([]*Version, len(tc.candidates))
+		for i, s := range tc.candidates {
+			vs[i] = MustParse(s)
+		}
+
+		nearest := Nearest(MustParse(tc.target), vs)
+		if tc.expected == "" {
+			if nearest != nil {
+				t.Errorf("Expected no nearest version for %s but got %s", tc.target, nearest)
+			}
+		} else if nearest == nil || nearest.Original() != tc.expected {
+			t.Errorf("Expected %s to be nearest to %s but got %v", tc.expected, tc.target, nearest)
+		}
+	}
+}
+
+func TestAggregateVersion(t *testing.T) {
+	components := []*Version{
+		MustParse("1.2.3"),
+		nil,
+		MustParse("1.2.10"),
+		MustParse("1.2.0-beta"),
+	}
+
+	tests := []struct {
+		components []*Version
+		strategy   string
+		expected   string
+	}{
+		{components, "max", "1.2.10"},
+		{components, "min", "1.2.0-beta"},
+		{components, "sum-patch", "1.2.13"},
+		{[]*Version{MustParse("v2.0.4")}, "sum-patch", "2.0.4"},
+		{[]*Version{MustParse("1.2.3"), MustParse("1.3.0")}, "sum-patch", ""},
+		{components, "avg", ""},
+		{[]*Version{MustParse("1.2.3")}, "avg", ""},
+		{nil, "max", ""},
+		{[]*Version{nil}, "min", ""},
+	}
+
+	for _, tc := range tests {
+		got := AggregateVersion(tc.components, tc.strategy)
+		if tc.expected == "" {
+			if got != nil {
+				t.Errorf("AggregateVersion(%v, %q) = %s, expected nil", tc.components, tc.strategy, got)
+			}
+			continue
+		}
+
+		if got == nil || got.String() != tc.expected {
+			t.Errorf("AggregateVersion(%v, %q) = %v, expected %s", tc.components, tc.strategy, got, tc.expected)
+		}
+	}
+}
@@ -2,44 +2,166 @@ package semver
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
+	"unicode"
 )
 
 // Constraints is one or more constraint that a semantic version can be
 // checked against.
 type Constraints struct {
 	constraints [][]*constraint
+
+	// checkOrder holds the same constraints as constraints but with each AND
+	// group ordered by checkCost, it is only used by Check. When nil the
+	// declaration order is used.
+	checkOrder [][]*constraint
+
+	// exact is the version of the single exact comparator when the
+	// constraints are one, so Check can compare with it directly.
+	exact *Version
+}
+
+// newConstraints creates a Constraints from the parsed OR groups.
+func newConstraints(or [][]*constraint) *Constraints {
+	cs := &Constraints{
+		constraints: or,
+		checkOrder:  orderByCheckCost(or),
+	}
+	if c := exactConstraint(or); c != nil {
+		cs.exact = c.con
+	}
+
+	return cs
+}
+
+// exactConstraint returns the comparator of constraints made of a single
+// exact `=` comparator without wildcards, like =1.2.3, or nil.
+func exactConstraint(or [][]*constraint) *constraint {
+	if len(or) != 1 || len(or[0]) != 1 {
+		return nil
+	}
+
+	c := or[0][0]
+	if c.origfunc != "=" || c.dirtyPart > 0 || c.anyPrerelease {
+		return nil
+	}
+
+	return c
+}
+
+// checkCost ranks how early a constraint should be evaluated by Check. Lower
+// ranks are the cheapest to evaluate or the most likely to reject a version.
+func (c *constraint) checkCost() int {
+	switch c.origfunc {
+	case ">", ">=", "=>", "<", "<=", "=<":
+		if c.dirtyPart > 0 { // always fails
+			return 0
+		}
+		return 2
+	case "=":
+		return 1
+	case "!=":
+		return 4
+	default: // tilde and caret
+		return 3
+	}
+}
+
+// orderByCheckCost returns a copy of or with every AND group ordered by
+// checkCost. The order within the same cost is kept.
+func orderByCheckCost(or [][]*constraint) [][]*constraint {
+	ordered := make([][]*constraint, len(or))
+	for k, group := range or {
+		g := append([]*constraint{}, group...)
+		sort.SliceStable(g, func(i, j int) bool {
+			return g[i].checkCost() < g[j].checkCost()
+		})
+		ordered[k] = g
+	}
+	return ordered
 }
 
 // NewConstraint returns a Constraints instance that a Version instance can
 // be checked against. If there is a parse error it will be returned.
+//
+// Comparators separated by spaces, commas or && must all be satisfied, groups
+// of them separated by || are alternatives, e.g. >=1.0.0 && <2.0.0 || ^3.
+//
+// Parse errors are returned as a *ConstraintParseError locating the offending
+// token in c.
 func NewConstraint(c string) (*Constraints, error) {
-	// Rewrite - ranges into a comparison operation.
-	c = rewriteRange(c)
+	// && is the same as the comma separating AND conditions, and - ranges are
+	// rewritten into comparison operations. The rewriting keeps track of the
+	// original text to report parse errors against input.
+	rw := newRewrittenConstraint(c)
+	rw.replaceAll("&&", ",")
+	rw.rewriteRange()
+	c = rw.s
 
 	ors := strings.Split(c, "||")
 	or := make([][]*constraint, len(ors))
+	start := 0
 	for k, v := range ors {
+		if k > 0 {
+			start += len(ors[k-1]) + len("||")
+		}
 
 		// TODO: Find a way to validate and fetch all the constraints in a simpler form
 
 		// Validate the segment
 		if !validConstraintRegex.MatchString(v) {
-			return nil, fmt.Errorf("improper constraint: %s", v)
+			valid := 0
+			if loc := validConstraintPrefixRegex.FindStringIndex(v); loc != nil {
+				valid = loc[1]
+			}
+
+			// Skip the separator following the valid comparators, if any.
+			pos := valid
+			sep := valid > 0
+			for pos < len(v) && (unicode.IsSpace(rune(v[pos])) || (sep && v[pos] == ',')) {
+				if v[pos] == ',' {
+					sep = false
+				}
+				pos++
+			}
+
+			token := v[pos:]
+			if i := strings.IndexByte(token, ','); i != -1 {
+				token = token[:i]
+			}
+			token = strings.TrimRightFunc(token, unicode.IsSpace)
+
+			switch {
+			case token == "" && pos < len(v):
+				// A separator with no comparator before it, e.g. ", >=1"
+				// or ">=1, , <2".
+				token = ","
+			case token == "":
+				// Nothing but separators is left, e.g. a trailing comma.
+				token = strings.TrimSpace(v[valid:])
+				pos = valid + strings.Index(v[valid:], token)
+			}
+
+			return nil, rw.parseError(start+pos, start+pos+len(token), start, start+len(v))
 		}
 
-		cs := findConstraintRegex.FindAllString(v, -1)
-		if cs == nil {
-			cs = append(cs, v)
+		locs := findConstraintRegex.FindAllStringIndex(v, -1)
+		if locs == nil {
+			locs = append(locs, []int{0, len(v)})
 		}
-		result := make([]*constraint, len(cs))
-		for i, s := range cs {
+		result := make([]*constraint, len(locs))
+		for i, loc := range locs {
+			s := v[loc[0]:loc[1]]
 			pc, err := parseConstraint(s)
 			if err != nil {
-				return nil, err
+				token := strings.TrimSpace(s)
+				pos := loc[0] + strings.Index(s, token)
+				return nil, rw.parseError(start+pos, start+pos+len(token), start+loc[0], start+loc[1])
 			}
 
 			result[i] = pc
@@ -47,16 +169,187 @@ func NewConstraint(c string) (*Constraints, error) {
 		or[k] = result
 	}
 
-	o := &Constraints{constraints: or}
-	return o, nil
+	return newConstraints(or), nil
+}
+
+// ConstraintParseError is returned by NewConstraint when a constraint string
+// cannot be parsed. Offset and Token locate the offending part of Input, e.g.
+// to underline it in an editor.
+type ConstraintParseError struct {
+	// Input is the constraint string given to NewConstraint.
+	Input string
+
+	// Offset is the byte offset of Token in Input.
+	Offset int
+
+	// Token is the offending substring of Input.
+	Token string
+
+	// Constraint is the AND group or the single comparator of Input that was
+	// rejected.
+	Constraint string
+}
+
+func (e *ConstraintParseError) Error() string {
+	return "improper constraint: " + e.Constraint
+}
+
+// rewrittenConstraint is a constraint string being rewritten by NewConstraint.
+// For every byte of s it records which bytes of the original string it comes
+// from, so that positions in s can be reported in the original string.
+type rewrittenConstraint struct {
+	orig string
+	s    string
+
+	// The byte s[i] comes from orig[start[i]:end[i]].
+	start, end []int
+}
+
+func newRewrittenConstraint(c string) *rewrittenConstraint {
+	rw := &rewrittenConstraint{
+		orig:  c,
+		s:     c,
+		start: make([]int, len(c)),
+		end:   make([]int, len(c)),
+	}
+	for i := range c {
+		rw.start[i], rw.end[i] = i, i+1
+	}
+
+	return rw
+}
+
+// replace replaces the non empty, ordered and non overlapping locs of s with
+// the text returned by repl for each of them.
+func (rw *rewrittenConstraint) replace(locs [][]int, repl func(loc []int) string) {
+	var b strings.Builder
+	start := make([]int, 0, len(rw.start))
+	end := make([]int, 0, len(rw.end))
+
+	prev := 0
+	for _, loc := range locs {
+		b.WriteString(rw.s[prev:loc[0]])
+		start = append(start, rw.start[prev:loc[0]]...)
+		end = append(end, rw.end[prev:loc[0]]...)
+
+		// The whole replacement comes from the whole replaced text.
+		t := repl(loc)
+		b.WriteString(t)
+		for range t {
+			start = append(start, rw.start[loc[0]])
+			end = append(end, rw.end[loc[1]-1])
+		}
+
+		prev = loc[1]
+	}
+	b.WriteString(rw.s[prev:])
+	start = append(start, rw.start[prev:]...)
+	end = append(end, rw.end[prev:]...)
+
+	rw.s, rw.start, rw.end = b.String(), start, end
+}
+
+// replaceAll replaces all the occurrences of old in s by new.
+func (rw *rewrittenConstraint) replaceAll(old, new string) {
+	var locs [][]int
+	for i := 0; ; {
+		j := strings.Index(rw.s[i:], old)
+		if j == -1 {
+			break
+		}
+		locs = append(locs, []int{i + j, i + j + len(old)})
+		i += j + len(old)
+	}
+
+	rw.replace(locs, func([]int) string { return new })
+}
+
+// rewriteRange rewrites the - ranges of s into comparison operations.
+func (rw *rewrittenConstraint) rewriteRange() {
+	s := rw.s
+	rw.replace(constraintRangeRegex.FindAllStringSubmatchIndex(s, -1), func(m []int) string {
+		return fmt.Sprintf(">= %s, <= %s ", s[m[2]:m[3]], s[m[22]:m[23]])
+	})
+}
+
+// span returns the part of the original string that s[i:j] comes from.
+func (rw *rewrittenConstraint) span(i, j int) (int, int) {
+	switch {
+	case i < j:
+		return rw.start[i], rw.end[j-1]
+	case i < len(rw.start):
+		return rw.start[i], rw.start[i]
+	default:
+		return len(rw.orig), len(rw.orig)
+	}
+}
+
+// parseError builds a ConstraintParseError for the token s[i:j] found in the
+// rejected constraint s[ci:cj].
+func (rw *rewrittenConstraint) parseError(i, j, ci, cj int) *ConstraintParseError {
+	i, j = rw.span(i, j)
+	ci, cj = rw.span(ci, cj)
+
+	return &ConstraintParseError{
+		Input:      rw.orig,
+		Offset:     i,
+		Token:      rw.orig[i:j],
+		Constraint: rw.orig[ci:cj],
+	}
+}
+
+// NewConstraintFromSlice returns the constraints matching all the given
+// comparators, e.g. []string{">=1.2.3", "<2.0.0"} is the same as
+// NewConstraint(">=1.2.3, <2.0.0"). Comparators must not contain ||.
+func NewConstraintFromSlice(parts []string) (*Constraints, error) {
+	for _, p := range parts {
+		if strings.Contains(p, "||") {
+			return nil, fmt.Errorf("improper constraint: %s, || is not allowed in a constraint slice", p)
+		}
+	}
+
+	return NewConstraint(strings.Join(parts, ", "))
+}
+
+// NewConstraintStrict is like NewConstraint but requires an explicit operator
+// for every comparator. A bare version like 1.2.3, which NewConstraint treats
+// as ~1.2.3, is rejected to avoid mistaking it for an exact version. Bare
+// wildcards like 1.2.x or * are still allowed.
+func NewConstraintStrict(c string) (*Constraints, error) {
+	cs, err := NewConstraint(c)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, group := range cs.constraints {
+		for _, pc := range group {
+			if pc.origfunc == "" && pc.dirtyPart == 0 {
+				return nil, fmt.Errorf("constraint %s has no operator, use =%s for the exact version or ~%s for the range", pc.orig, pc.orig, pc.orig)
+			}
+		}
+	}
+
+	return cs, nil
 }
 
 // Check tests if a version satisfies the constraints.
+//
+// Within each AND group the cheapest and most selective constraints are
+// evaluated first, so a version is rejected as early as possible.
 func (cs Constraints) Check(v *Version) bool {
+	if cs.exact != nil { // fast path for pinned versions, see constraintEqual
+		return v.equalAndCheckMetadata(cs.exact)
+	}
+
 	// TODO(mattfarina): For v4 of this library consolidate the Check and Validate
 	// functions as the underlying functions make that possible now.
+	ors := cs.checkOrder
+	if ors == nil {
+		ors = cs.constraints
+	}
+
 	// loop over the ORs and check the inner ANDs
-	for _, o := range cs.constraints {
+	for _, o := range ors {
 		joy := true
 		for _, c := range o {
 			if check, _ := c.check(v); !check {
@@ -73,6 +366,41 @@ func (cs Constraints) Check(v *Version) bool {
 	return false
 }
 
+// FirstUnsatisfied checks v against every constraints in order and returns
+// the index and the first one v does not satisfy, or -1 and nil if v satisfies
+// them all. This allows reporting which of several layered policies blocks a
+// version.
+func FirstUnsatisfied(v *Version, cs ...*Constraints) (int, *Constraints) {
+	for i, c := range cs {
+		if !c.Check(v) {
+			return i, c
+		}
+	}
+
+	return -1, nil
+}
+
+// SelectMVS returns the version that minimal version selection, as used by
+// Go modules, picks from available: the lowest one satisfying all the
+// constraints according to Check. Unlike picking the latest match, this keeps
+// builds reproducible when newer versions are published.
+//
+// Nil versions are ignored and nil is returned when none satisfies them all.
+func SelectMVS(constraints []*Constraints, available []*Version) *Version {
+	var selected *Version
+	for _, v := range available {
+		if v == nil || (selected != nil && !v.LessThan(selected)) {
+			continue
+		}
+
+		if i, _ := FirstUnsatisfied(v, constraints...); i == -1 {
+			selected = v
+		}
+	}
+
+	return selected
+}
+
 // Validate checks if a version satisfies a constraint. If not a slice of
 // reasons for the failure are returned in addition to a bool.
 func (cs Constraints) Validate(v *Version) (bool, []error) {
@@ -87,7 +415,7 @@ func (cs Constraints) Validate(v *Version) (bool, []error) {
 		for _, c := range o {
 			// Before running the check handle the case there the version is
 			// a prerelease and the check is not searching for prereleases.
-			if c.con.pre == "" && v.pre != "" {
+			if c.rejectsPrerelease(v) {
 				if !prerelesase {
 					em := fmt.Errorf("%s is a prerelease version and the constraint is only looking for release versions", v)
 					e = append(e, em)
@@ -110,6 +438,254 @@ func (cs Constraints) Validate(v *Version) (bool, []error) {
 	return false, e
 }
 
+// ValidateByBranch checks a version against every OR group of the constraints
+// like Validate, but returns the reasons for the failure separately for each
+// group, in their order. The slice of a group the version satisfies is empty.
+//
+// For ^1.0.0 || ^2.0.0 and 3.0.0 it returns one reason for each group, both
+// about the major version being different.
+func (cs Constraints) ValidateByBranch(v *Version) [][]error {
+	res := make([][]error, len(cs.constraints))
+
+	for k, group := range cs.constraints {
+		var e []error
+		prerelease := false
+		for _, c := range group {
+			if c.rejectsPrerelease(v) {
+				if !prerelease {
+					e = append(e, fmt.Errorf("%s is a prerelease version and the constraint is only looking for release versions", v))
+					prerelease = true
+				}
+			} else if _, err := c.check(v); err != nil {
+				e = append(e, err)
+			}
+		}
+		res[k] = e
+	}
+
+	return res
+}
+
+// CheckResult is the outcome of CheckDetailed.
+type CheckResult int
+
+const (
+	// Rejected means the version does not satisfy the constraints.
+	Rejected CheckResult = iota
+
+	// Satisfied means the version satisfies the constraints, like Validate.
+	Satisfied
+
+	// RejectedPrereleaseOnly means the version is a prerelease that would
+	// satisfy the constraints if prereleases were included, i.e. Check accepts
+	// it but Validate does not.
+	RejectedPrereleaseOnly
+)
+
+// CheckDetailed checks if a version satisfies the constraints like Validate,
+// but tells apart versions only rejected because they are prereleases while
+// the constraints are looking for release versions.
+func (cs Constraints) CheckDetailed(v *Version) CheckResult {
+	res := Rejected
+	for _, group := range cs.constraints {
+		groupRes := Satisfied
+		for _, c := range group {
+			r := c.checkDetailed(v)
+			if r == Rejected {
+				groupRes = Rejected
+				break
+			}
+			if r == RejectedPrereleaseOnly {
+				groupRes = r
+			}
+		}
+
+		if groupRes == Satisfied {
+			return Satisfied
+		}
+		if groupRes == RejectedPrereleaseOnly {
+			res = groupRes
+		}
+	}
+
+	return res
+}
+
+// ExcludeVersions returns a copy of base where every AND group also requires
+// the version not to be any of bad, so Check only passes versions that satisfy
+// base and are not in bad. Exclusions match the exact version, including
+// prerelease, and ignore metadata unless the bad version has some.
+//
+// Nil entries of bad are skipped. A nil base has no requirement besides the
+// exclusions.
+func ExcludeVersions(base *Constraints, bad []*Version) *Constraints {
+	excludes := make([]*constraint, 0, len(bad))
+	for _, v := range bad {
+		if v == nil {
+			continue
+		}
+
+		con := v.Copy()
+		excludes = append(excludes, &constraint{
+			con:      &con,
+			orig:     v.String(),
+			origfunc: "!=",
+		})
+	}
+
+	if base == nil {
+		return newConstraints([][]*constraint{excludes})
+	}
+
+	or := make([][]*constraint, len(base.constraints))
+	for k, group := range base.constraints {
+		and := make([]*constraint, 0, len(group)+len(excludes))
+		and = append(and, group...)
+		or[k] = append(and, excludes...)
+	}
+
+	return newConstraints(or)
+}
+
+// Dedup returns a copy of the constraints where exact duplicate comparators
+// (same operator and version as written) within each AND group are removed,
+// keeping the first one. For example >=1.0.0 >=1.0.0 <2.0.0 becomes
+// >=1.0.0 <2.0.0.
+func (cs Constraints) Dedup() *Constraints {
+	or := make([][]*constraint, len(cs.constraints))
+	for k, group := range cs.constraints {
+		seen := make(map[string]bool, len(group))
+		and := make([]*constraint, 0, len(group))
+		for _, c := range group {
+			key := c.string()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			and = append(and, c)
+		}
+		or[k] = and
+	}
+
+	return newConstraints(or)
+}
+
+// Sorted returns a copy of the constraints in a canonical order, so that
+// equivalent constraints written in a different order have the same String().
+// Comparators within each AND group are sorted by their operator and then by
+// their version, the OR groups are then sorted by their string form.
+func (cs Constraints) Sorted() *Constraints {
+	or := make([][]*constraint, len(cs.constraints))
+	for k, group := range cs.constraints {
+		and := append([]*constraint{}, group...)
+		sort.SliceStable(and, func(i, j int) bool {
+			return compareConstraint(and[i], and[j]) < 0
+		})
+		or[k] = and
+	}
+
+	sort.SliceStable(or, func(i, j int) bool {
+		return groupString(or[i]) < groupString(or[j])
+	})
+
+	return newConstraints(or)
+}
+
+// compareConstraint orders constraints by operator, version and then the
+// version as written (e.g. 1.x and 1 have the same version).
+func compareConstraint(a, b *constraint) int {
+	if d := strings.Compare(a.origfunc, b.origfunc); d != 0 {
+		return d
+	}
+	if d := a.con.CompareTotal(b.con); d != 0 {
+		return d
+	}
+	return strings.Compare(a.orig, b.orig)
+}
+
+// groupString returns the string of an AND group as used by String.
+func groupString(group []*constraint) string {
+	s := make([]string, len(group))
+	for i, c := range group {
+		s[i] = c.string()
+	}
+	return strings.Join(s, " ")
+}
+
+// IsPinned reports whether the constraints consist of a single exact `=`
+// comparator without wildcards, like =1.2.3, and returns the pinned version.
+// Note that a bare version like 1.2.3 is a tilde range and is not pinned.
+func (cs Constraints) IsPinned() (*Version, bool) {
+	c := exactConstraint(cs.constraints)
+	if c == nil {
+		return nil, false
+	}
+
+	v := c.con.Copy()
+	return &v, true
+}
+
+// IsExact reports whether the constraints only match a single version, see
+// IsPinned. Check is faster for such constraints.
+func (cs Constraints) IsExact() bool {
+	return exactConstraint(cs.constraints) != nil
+}
+
+// AllowsPrereleases reports whether any comparator opts into prerelease
+// matching, either by having a prerelease in its version (e.g. >=1.2.3-rc.1)
+// or with the -* wildcard (e.g. =1.2.3-*).
+//
+// Note that Validate only accepts a prerelease for an AND group in which every
+// comparator opts in, so >=1.0.0-0, <2.0.0 still rejects 1.5.0-rc.1.
+func (cs Constraints) AllowsPrereleases() bool {
+	for _, group := range cs.constraints {
+		for _, c := range group {
+			if c.con.pre != "" || c.anyPrerelease {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// FilterWithReasons splits versions into the ones that satisfy the
+// constraints and the ones that do not. Versions are evaluated with Validate
+// and the reasons for every rejected version are kept in rejected.
+func (cs Constraints) FilterWithReasons(versions []*Version) (matched []*Version, rejected map[*Version][]error) {
+	rejected = make(map[*Version][]error)
+
+	for _, v := range versions {
+		if ok, errs := cs.Validate(v); ok {
+			matched = append(matched, v)
+		} else {
+			rejected[v] = errs
+		}
+	}
+
+	return matched, rejected
+}
+
+// AdditionalMatchesIfPrerelease returns the versions, in their order, that
+// Validate rejects only because they are prereleases while the constraint is
+// looking for release versions, i.e. the versions that would match if
+// prereleases were included. Check does not apply that rule, so these are the
+// versions accepted by Check but not by Validate.
+func (cs Constraints) AdditionalMatchesIfPrerelease(versions []*Version) []*Version {
+	var res []*Version
+	for _, v := range versions {
+		if !v.IsPrerelease() || !cs.Check(v) {
+			continue
+		}
+
+		if ok, _ := cs.Validate(v); !ok {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
 func (cs Constraints) String() string {
 	buf := make([]string, len(cs.constraints))
 	var tmp bytes.Buffer
@@ -131,6 +707,38 @@ func (cs Constraints) String() string {
 	return strings.Join(buf, " || ")
 }
 
+// UnmarshalJSON implements the json.Unmarshaler interface. Both a string and
+// an array of comparators, see NewConstraintFromSlice, are accepted. A JSON
+// null leaves the constraints unchanged.
+func (cs *Constraints) UnmarshalJSON(b []byte) error {
+	t := bytes.TrimSpace(b)
+	if string(t) == "null" {
+		return nil
+	}
+
+	if len(t) == 0 || t[0] != '[' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		return cs.UnmarshalText([]byte(s))
+	}
+
+	var parts []string
+	if err := json.Unmarshal(b, &parts); err != nil {
+		return err
+	}
+
+	temp, err := NewConstraintFromSlice(parts)
+	if err != nil {
+		return err
+	}
+
+	*cs = *temp
+
+	return nil
+}
+
 // UnmarshalText implements the encoding.TextUnmarshaler interface.
 func (cs *Constraints) UnmarshalText(text []byte) error {
 	temp, err := NewConstraint(string(text))
@@ -158,8 +766,11 @@ var findConstraintRegex *regexp.Regexp
 // Used to validate an segment of ANDs is valid
 var validConstraintRegex *regexp.Regexp
 
+// Used to find the longest valid start of an invalid segment of ANDs
+var validConstraintPrefixRegex *regexp.Regexp
+
 const cvRegex string = `v?([0-9|x|X|\*]+)((\.[0-9|x|X|\*]+)*)` +
-	`(-([0-9A-Za-z\-]+(\.[0-9A-Za-z\-]+)*))?` +
+	`(-(\*|[0-9A-Za-z\-]+(\.[0-9A-Za-z\-]+)*))?` +
 	`(\+([0-9A-Za-z\-]+(\.[0-9A-Za-z\-]+)*))?`
 
 func init() {
@@ -203,6 +814,14 @@ func init() {
 		cvRegex,
 		ops,
 		cvRegex))
+
+	validConstraintPrefixRegex = regexp.MustCompile(fmt.Sprintf(
+		`^(\s*(%s)\s*(%s)\s*)((?:\s+|,\s*)(%s)\s*(%s)\s*)*`,
+		ops,
+		cvRegex,
+		ops,
+		cvRegex))
+	validConstraintPrefixRegex.Longest()
 }
 
 // An individual constraint
@@ -219,6 +838,10 @@ type constraint struct {
 
 	// When an x is used as part of the version (e.g., 1.x)
 	dirtyPart int
+
+	// When a * is used as the prerelease (e.g., =1.2.3-*), any prerelease of
+	// the version as well as the version itself is matched
+	anyPrerelease bool
 }
 
 // Check if a version meets the constraint
@@ -226,6 +849,28 @@ func (c *constraint) check(v *Version) (bool, error) {
 	return constraintOps[c.origfunc](v, c)
 }
 
+// rejectsPrerelease reports whether the constraint rejects the version for
+// being a prerelease when it is only looking for release versions. Validate
+// applies this before the comparison itself.
+func (c *constraint) rejectsPrerelease(v *Version) bool {
+	return c.con.pre == "" && !c.anyPrerelease && v.pre != ""
+}
+
+// checkDetailed checks if a version meets the constraint like Validate does,
+// returning RejectedPrereleaseOnly when it is only rejected by
+// rejectsPrerelease.
+func (c *constraint) checkDetailed(v *Version) CheckResult {
+	if ok, _ := c.check(v); !ok {
+		return Rejected
+	}
+
+	if c.rejectsPrerelease(v) {
+		return RejectedPrereleaseOnly
+	}
+
+	return Satisfied
+}
+
 // String prints an individual constraint into a string
 func (c *constraint) string() string {
 	return c.origfunc + c.orig
@@ -257,6 +902,15 @@ func parseConstraint(c string) (*constraint, error) {
 			preAndMeta = ver[i:]
 		}
 
+		if strings.HasPrefix(preAndMeta, "-*") {
+			if cs.origfunc != "=" && cs.origfunc != "!=" {
+				return nil, fmt.Errorf("improper constraint: %s", c)
+			}
+
+			cs.anyPrerelease = true
+			preAndMeta = preAndMeta[2:]
+		}
+
 		dirtyPart := 0
 		verParts := strings.Split(verWithoutTrailing, ".")
 		for i, p := range verParts {
@@ -322,6 +976,13 @@ func maxNonDirtyPartsNumberOf(v *Version, c *constraint) int {
 
 // Constraint functions
 func constraintNotEqual(v *Version, c *constraint) (bool, error) {
+	if c.anyPrerelease {
+		if v.equalCoreAndCheckMetadata(c.con) {
+			return false, fmt.Errorf("%s is equal to %s", v, c.orig)
+		}
+		return true, nil
+	}
+
 	if c.dirtyPart > 0 {
 		for i := 1; i < maxNonDirtyPartsNumberOf(v, c); i++ {
 			if c.con.Part(i) != v.Part(i) {
@@ -446,8 +1107,25 @@ func (v *Version) equalAndCheckMetadata(another *Version) bool {
 	return true
 }
 
+// equalCoreAndCheckMetadata is like equalAndCheckMetadata but ignores the
+// prerelease.
+func (v *Version) equalCoreAndCheckMetadata(another *Version) bool {
+	if firstDifferentPart(v, another) != 0 {
+		return false
+	}
+	if cm, sm := another.metadata, v.metadata; cm != "" && cm != sm {
+		return false
+	}
+	return true
+}
+
 func constraintEqual(v *Version, c *constraint) (bool, error) {
-	eq := v.equalAndCheckMetadata(c.con)
+	var eq bool
+	if c.anyPrerelease {
+		eq = v.equalCoreAndCheckMetadata(c.con)
+	} else {
+		eq = v.equalAndCheckMetadata(c.con)
+	}
 	if eq {
 		return true, nil
 	}
@@ -506,6 +1184,43 @@ func constraintCaret(v *Version, c *constraint) (bool, error) {
 	return true, nil
 }
 
+// SatisfiesCaret reports whether v satisfies ^base, without building the
+// constraint from a string. Like Check, prereleases are not rejected.
+func (v *Version) SatisfiesCaret(base *Version) bool {
+	ok, _ := constraintCaret(v, &constraint{con: base, orig: base.String(), origfunc: "^"})
+	return ok
+}
+
+// SatisfiesTilde reports whether v satisfies ~base, without building the
+// constraint from a string. Like Check, prereleases are not rejected.
+func (v *Version) SatisfiesTilde(base *Version) bool {
+	ok, _ := constraintTilde(v, &constraint{con: base, orig: base.String(), origfunc: "~"})
+	return ok
+}
+
+// SatisfiesAny parses the constraints and reports whether v satisfies at
+// least one of them according to Check, e.g. to allow several supported
+// release lines. All the constraints are parsed first, so the first parse
+// error is returned even if v satisfies an earlier constraint.
+func (v *Version) SatisfiesAny(constraints ...string) (bool, error) {
+	parsed := make([]*Constraints, len(constraints))
+	for i, c := range constraints {
+		cs, err := NewConstraint(c)
+		if err != nil {
+			return false, err
+		}
+		parsed[i] = cs
+	}
+
+	for _, cs := range parsed {
+		if cs.Check(v) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 func isX(x string) bool {
 	switch x {
 	case "x", "*", "X":
@@ -516,15 +1231,192 @@ func isX(x string) bool {
 }
 
 func rewriteRange(i string) string {
-	m := constraintRangeRegex.FindAllStringSubmatch(i, -1)
-	if m == nil {
-		return i
+	rw := newRewrittenConstraint(i)
+	rw.rewriteRange()
+
+	return rw.s
+}
+
+// AsRangeConstraint converts a (possibly partial) version into the range it
+// naturally describes.
+//
+// 1      -->  >=1.0.0 <2.0.0
+// 1.2    -->  >=1.2.0 <1.3.0
+// 1.2.3  -->  =1.2.3
+//
+// Versions with three or more parts are treated as exact.
+func (v *Version) AsRangeConstraint() *Constraints {
+	var c string
+	switch n := len(v.parts); {
+	case n <= 1:
+		major := v.Part(1)
+		c = fmt.Sprintf(">=%s <%d.0.0", rangeLowerBound(v, 3), major+1)
+	case n == 2:
+		c = fmt.Sprintf(">=%s <%d.%d.0", rangeLowerBound(v, 3), v.Major(), v.Minor()+1)
+	default:
+		c = "=" + v.String()
+	}
+
+	return mustNewConstraint(c)
+}
+
+// DriftConstraint builds the constraint allowing v and the versions up to the
+// given drift ahead of it, for policies like "at most 2 minor versions ahead"
+// that neither ^ nor ~ express. For example for 1.2.3:
+//
+//	majorDrift=1  -->  >=1.2.3 <3.0.0
+//	minorDrift=2  -->  >=1.2.3 <1.5.0
+//	patchDrift=2  -->  >=1.2.3 <1.2.6
+//	no drift      -->  >=1.2.3 <1.2.4
+//
+// The highest component with a drift decides the upper bound, any patch of
+// the allowed minors being accepted; the drifts of the lower components are
+// then ignored, as moving to a later minor resets the patch anyway. If the
+// upper bound would overflow there is none. The metadata of v is ignored.
+func (v *Version) DriftConstraint(majorDrift, minorDrift, patchDrift uint64) *Constraints {
+	n := len(v.parts)
+	if n < 3 {
+		n = 3
+	}
+	c := ">=" + rangeLowerBound(v, n)
+
+	major, minor, patch := v.Part(1), v.Minor(), v.Patch()
+	switch {
+	case majorDrift > 0:
+		if major+majorDrift+1 > major {
+			c += fmt.Sprintf(" <%d.0.0", major+majorDrift+1)
+		}
+	case minorDrift > 0:
+		if minor+minorDrift+1 > minor {
+			c += fmt.Sprintf(" <%d.%d.0", major, minor+minorDrift+1)
+		}
+	default:
+		if patch+patchDrift+1 > patch {
+			c += fmt.Sprintf(" <%d.%d.%d", major, minor, patch+patchDrift+1)
+		}
+	}
+
+	return mustNewConstraint(c)
+}
+
+// SuggestConstraint returns a constraint for depending on v with the given
+// pinning style, for 1.2.3:
+//   - "exact": =1.2.3
+//   - "caret": ^1.2.3, any later version with the same major version
+//   - "tilde": ~1.2.3, any later version with the same minor version
+//   - "minor": 1.2.x, any version with the same minor version
+//
+// The prerelease of v is kept (except for "minor") but the metadata is not.
+// nil is returned for an unknown style.
+func (v *Version) SuggestConstraint(style string) *Constraints {
+	n := len(v.parts)
+	if n == 0 {
+		n = 1
+	}
+	base := rangeLowerBound(v, n)
+
+	switch style {
+	case "exact":
+		return mustNewConstraint("=" + base)
+	case "caret":
+		return mustNewConstraint("^" + base)
+	case "tilde":
+		return mustNewConstraint("~" + base)
+	case "minor":
+		return mustNewConstraint(fmt.Sprintf("%d.%d.x", v.Part(1), v.Minor()))
+	default:
+		return nil
+	}
+}
+
+// rangeLowerBound returns v padded with zeros to n parts, keeping the
+// prerelease but dropping the metadata.
+func rangeLowerBound(v *Version, n int) string {
+	parts := make([]uint64, n)
+	copy(parts, v.parts)
+
+	s := joinNumbers(parts)
+	if v.pre != "" {
+		s += "-" + v.pre
+	}
+	return s
+}
+
+// TightestCaret returns the narrowest ^ constraint that all the versions
+// satisfy (according to Check), e.g. ^1.2.3 for 1.2.3, 1.4.0 and 1.9.1. The
+// lowest version is used as the base, for 0.y.z versions it is shortened as
+// needed, so 0.2.3 and 0.3.1 give ^0 (which is <1.0.0).
+//
+// An error is returned when the versions span multiple major versions, as no
+// caret constraint can cover them, or when there are no versions. Nil entries
+// are ignored.
+func TightestCaret(versions []*Version) (*Constraints, error) {
+	var low, high *Version
+	for _, v := range versions {
+		if v == nil {
+			continue
+		}
+		if low == nil || v.LessThan(low) {
+			low = v
+		}
+		if high == nil || v.GreaterThan(high) {
+			high = v
+		}
+	}
+
+	if low == nil {
+		return nil, errors.New("no versions to build a caret constraint from")
+	}
+
+	n := len(low.parts)
+	if n == 0 {
+		n = 1
+	}
+
+	bases := []string{rangeLowerBound(low, n)}
+	for ; n > 1; n-- {
+		bases = append(bases, joinNumbers(low.parts[:n-1]))
+	}
+
+	for _, base := range bases {
+		c := mustNewConstraint("^" + base)
+		if checkAllVersions(c, versions) {
+			return c, nil
+		}
+	}
+
+	return nil, fmt.Errorf("versions span multiple major versions (%s and %s), no caret constraint covers them", low, high)
+}
+
+// checkAllVersions reports whether all non nil versions satisfy c.
+func checkAllVersions(c *Constraints, versions []*Version) bool {
+	for _, v := range versions {
+		if v != nil && !c.Check(v) {
+			return false
+		}
 	}
-	o := i
-	for _, v := range m {
-		t := fmt.Sprintf(">= %s, <= %s ", v[1], v[11])
-		o = strings.Replace(o, v[0], t, 1)
+	return true
+}
+
+// mustNewConstraint is like NewConstraint but panics on error. It is only used
+// for constraints generated by this package which are known to be valid.
+func mustNewConstraint(c string) *Constraints {
+	cs, err := NewConstraint(c)
+	if err != nil {
+		panic(err)
+	}
+	return cs
+}
+
+// PrereleaseWildcard returns a constraint matching v and any prerelease of it,
+// e.g. =1.2.3-* for 1.2.3 which matches 1.2.3, 1.2.3-alpha and 1.2.3-rc.1.
+// The prerelease and metadata of v are ignored, a version without numeric
+// parts, like the zero Version, is taken as 0.
+func PrereleaseWildcard(v *Version) *Constraints {
+	parts := v.parts
+	if len(parts) == 0 {
+		parts = []uint64{0}
 	}
 
-	return o
+	return mustNewConstraint("=" + joinNumbers(parts) + "-*")
 }
@@ -3,8 +3,11 @@ package semver
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/ImSingee/tt"
@@ -276,6 +279,74 @@ func TestNewConstraint(t *testing.T) {
 	}
 }
 
+func TestNewConstraintAndOperator(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		in    []string
+		out   []string
+	}{
+		{">=1.0.0 && <2.0.0", ">=1.0.0 <2.0.0", []string{"1.0.0", "1.9.9"}, []string{"0.9.0", "2.0.0"}},
+		{">=1.0.0&&<2.0.0", ">=1.0.0 <2.0.0", []string{"1.5.0"}, []string{"2.0.0"}},
+		{">=1.0.0 && <2.0.0 || >=3.0.0 && <4.0.0", ">=1.0.0 <2.0.0 || >=3.0.0 <4.0.0", []string{"1.5.0", "3.5.0"}, []string{"2.5.0", "4.0.0"}},
+		{"^1 || >=3.0.0 && !=3.1.0", "^1 || >=3.0.0 !=3.1.0", []string{"1.5.0", "3.0.0", "3.2.0"}, []string{"2.0.0", "3.1.0"}},
+		{"1.0 - 2.0 && !=1.5.0", ">=1.0 <=2.0 !=1.5.0", []string{"1.4.0"}, []string{"1.5.0", "2.1.0"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			c, err := NewConstraint(tc.input)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.want, c.String())
+
+			for _, v := range tc.in {
+				tt.AssertTrue(t, c.Check(MustParse(v)))
+			}
+			for _, v := range tc.out {
+				tt.AssertFalse(t, c.Check(MustParse(v)))
+			}
+		})
+	}
+
+	for _, input := range []string{"&& >=1.0.0", ">=1.0.0 &&", ">=1.0.0 && && <2.0.0", ">=1.0.0 & <2.0.0"} {
+		t.Run(input, func(t *testing.T) {
+			_, err := NewConstraint(input)
+			tt.AssertIsError(t, err)
+		})
+	}
+}
+
+func TestNewConstraintStrict(t *testing.T) {
+	tests := []struct {
+		input string
+		err   string
+	}{
+		{"=1.2.3", ""},
+		{"^1.2.3 || ~2.0", ""},
+		{">= 1.2.3, < 2.0", ""},
+		{"1.0.0 - 2.0.0", ""},
+		{"1.2.x", ""},
+		{"*", ""},
+		{"1.2.3", "constraint 1.2.3 has no operator, use =1.2.3 for the exact version or ~1.2.3 for the range"},
+		{">=1.0.0, 1.2", "constraint 1.2 has no operator, use =1.2 for the exact version or ~1.2 for the range"},
+		{"^1 || 2", "constraint 2 has no operator, use =2 for the exact version or ~2 for the range"},
+		{">= bar", "improper constraint: >= bar"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.input, func(t *testing.T) {
+			c, err := NewConstraintStrict(tc.input)
+			if tc.err == "" {
+				tt.AssertIsNotError(t, err)
+				tt.AssertEqual(t, mustNewConstraint(tc.input).String(), c.String())
+			} else {
+				tt.AssertIsError(t, err)
+				tt.AssertEqual(t, tc.err, err.Error())
+			}
+		})
+	}
+}
+
 func TestConstraintsCheck(t *testing.T) {
 	tests := []struct {
 		constraint string
@@ -462,6 +533,179 @@ func TestConstraintsCheck(t *testing.T) {
 	}
 }
 
+func TestConstraintsCheckOrder(t *testing.T) {
+	c, err := NewConstraint(">=1.0.0 !=1.2.3 ^1 =1.5.0, <1.x || !=2.0.0 ~2")
+	tt.AssertIsNotError(t, err)
+
+	got := make([]string, len(c.checkOrder))
+	for i, o := range c.checkOrder {
+		got[i] = Constraints{constraints: [][]*constraint{o}}.String()
+	}
+	tt.AssertEqual(t, []string{"<1.x =1.5.0 >=1.0.0 ^1 !=1.2.3", "~2 !=2.0.0"}, got)
+
+	// declaration order is kept for everything else
+	tt.AssertEqual(t, ">=1.0.0 !=1.2.3 ^1 =1.5.0 <1.x || !=2.0.0 ~2", c.String())
+}
+
+func benchCheckCandidates() []*Version {
+	vs := make([]*Version, 0, 10*10*10)
+	for major := uint64(0); major < 10; major++ {
+		for minor := uint64(0); minor < 10; minor++ {
+			for patch := uint64(0); patch < 10; patch++ {
+				vs = append(vs, NewVersionByParts(major, minor, patch))
+			}
+		}
+	}
+	return vs
+}
+
+func benchConstraintsCheck(b *testing.B, c *Constraints) {
+	vs := benchCheckCandidates()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, v := range vs {
+			c.Check(v)
+		}
+	}
+}
+
+func BenchmarkConstraintsCheckOrdered(b *testing.B) {
+	c, _ := NewConstraint("!=1.2.3 ^1.0.0 >=1.1.0 <1.5.0")
+	benchConstraintsCheck(b, c)
+}
+
+func BenchmarkConstraintsCheckDeclarationOrder(b *testing.B) {
+	c, _ := NewConstraint("!=1.2.3 ^1.0.0 >=1.1.0 <1.5.0")
+	c.checkOrder = nil
+	benchConstraintsCheck(b, c)
+}
+
+func BenchmarkConstraintsCheckExact(b *testing.B) {
+	c, _ := NewConstraint("=1.2.3")
+	benchConstraintsCheck(b, c)
+}
+
+func BenchmarkConstraintsCheckExactGeneral(b *testing.B) {
+	c, _ := NewConstraint("=1.2.3")
+	c.exact = nil
+	benchConstraintsCheck(b, c)
+}
+
+func TestConstraintsPrereleaseWildcard(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		check      bool
+	}{
+		{"=1.2.3-*", "1.2.3", true},
+		{"=1.2.3-*", "1.2.3-alpha", true},
+		{"=1.2.3-*", "1.2.3-rc.1+build", true},
+		{"=1.2.3-*", "1.2.3.0-0", true},
+		{"=1.2.3-*", "1.2.4-alpha", false},
+		{"=1.2.3-*", "1.2.2", false},
+		{"=1.2.3-*", "1.2.3.1", false},
+		{"=1.2-*", "1.2.0-beta", true},
+		{"=1.2.3-*+meta", "1.2.3-beta+meta", true},
+		{"=1.2.3-*+meta", "1.2.3-beta+other", false},
+		{"!=1.2.3-*", "1.2.3-beta", false},
+		{"!=1.2.3-*", "1.2.3", false},
+		{"!=1.2.3-*", "1.2.4-beta", true},
+		{">=1.0.0-0, =1.2.3-*", "1.2.3-beta", true},
+	}
+
+	for _, tc := range tests {
+		t.Run("constraint "+tc.constraint+" to "+tc.version, func(t *testing.T) {
+			c, err := NewConstraint(tc.constraint)
+			tt.AssertIsNotError(t, err)
+
+			v := MustParse(tc.version)
+			tt.AssertEqual(t, tc.check, c.Check(v))
+
+			ok, _ := c.Validate(v)
+			tt.AssertEqual(t, tc.check, ok)
+		})
+	}
+
+	for _, s := range []string{">1.2.3-*", "^1.2.3-*", "~1.2.3-*", "1.2.3-*", "=1.2.3-*.1"} {
+		t.Run("invalid "+s, func(t *testing.T) {
+			_, err := NewConstraint(s)
+			tt.AssertIsError(t, err)
+		})
+	}
+
+	t.Run("PrereleaseWildcard", func(t *testing.T) {
+		c := PrereleaseWildcard(MustParse("v1.2.3-beta+meta"))
+		tt.AssertEqual(t, "=1.2.3-*", c.String())
+		tt.AssertTrue(t, c.Check(MustParse("1.2.3-alpha")))
+		tt.AssertTrue(t, c.Check(MustParse("1.2.3")))
+		tt.AssertFalse(t, c.Check(MustParse("1.2.4")))
+
+		c = PrereleaseWildcard(&Version{})
+		tt.AssertEqual(t, "=0-*", c.String())
+		tt.AssertTrue(t, c.Check(MustParse("0.0.0-alpha")))
+		tt.AssertFalse(t, c.Check(MustParse("0.0.1")))
+	})
+}
+
+func TestSatisfiesCaretAndTilde(t *testing.T) {
+	tests := []struct {
+		version string
+		base    string
+		caret   bool
+		tilde   bool
+	}{
+		{"1.2.3", "1.2.3", true, true},
+		{"1.4.0", "1.2.3", true, false},
+		{"1.2.9", "1.2.3", true, true},
+		{"2.0.0", "1.2.3", false, false},
+		{"1.2.2", "1.2.3", false, false},
+		{"0.2.5", "0.2.3", true, true},
+		{"0.3.0", "0.2.3", false, false},
+		{"1.5.0", "1", true, true},
+		{"v1.2.4", "v1.2", true, true},
+		{"1.3.0-beta", "1.2.3", true, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version+" "+tc.base, func(t *testing.T) {
+			v, base := MustParse(tc.version), MustParse(tc.base)
+			tt.AssertEqual(t, tc.caret, v.SatisfiesCaret(base))
+			tt.AssertEqual(t, tc.tilde, v.SatisfiesTilde(base))
+
+			tt.AssertEqual(t, mustNewConstraint("^"+base.String()).Check(v), v.SatisfiesCaret(base))
+			tt.AssertEqual(t, mustNewConstraint("~"+base.String()).Check(v), v.SatisfiesTilde(base))
+		})
+	}
+}
+
+func TestSatisfiesAny(t *testing.T) {
+	tests := []struct {
+		version     string
+		constraints []string
+		expected    bool
+		err         bool
+	}{
+		{"1.4.2", []string{"~1.4", "~2.1"}, true, false},
+		{"2.1.0", []string{"~1.4", "~2.1"}, true, false},
+		{"1.5.0", []string{"~1.4", "~2.1"}, false, false},
+		{"1.5.0", nil, false, false},
+		{"1.4.2", []string{"~1.4", ">= bar"}, false, true},
+		{"1.4.2", []string{">= bar", "~1.4"}, false, true},
+	}
+
+	for _, tc := range tests {
+		ok, err := MustParse(tc.version).SatisfiesAny(tc.constraints...)
+		if tc.err {
+			tt.AssertIsError(t, err)
+		} else {
+			tt.AssertIsNotError(t, err)
+		}
+		tt.AssertEqual(t, tc.expected, ok)
+	}
+}
+
 func TestRewriteRange(t *testing.T) {
 	tests := []struct {
 		c  string
@@ -503,6 +747,87 @@ func TestIsX(t *testing.T) {
 	}
 }
 
+func TestFirstUnsatisfied(t *testing.T) {
+	org, err := NewConstraint(">=1.0.0")
+	tt.AssertIsNotError(t, err)
+	team, err := NewConstraint("<2.0.0, !=1.2.3")
+	tt.AssertIsNotError(t, err)
+
+	tests := []struct {
+		version  string
+		expected int
+	}{
+		{"1.5.0", -1},
+		{"0.9.0", 0},
+		{"0.9.0-beta", 0},
+		{"1.2.3", 1},
+		{"2.0.0", 1},
+	}
+
+	for _, tc := range tests {
+		i, c := FirstUnsatisfied(MustParse(tc.version), org, team)
+		tt.AssertEqual(t, tc.expected, i)
+		switch tc.expected {
+		case -1:
+			tt.AssertIsNil(t, c)
+		case 0:
+			tt.AssertEqual(t, org, c)
+		case 1:
+			tt.AssertEqual(t, team, c)
+		}
+	}
+
+	i, c := FirstUnsatisfied(MustParse("1.2.3"))
+	tt.AssertEqual(t, -1, i)
+	tt.AssertIsNil(t, c)
+}
+
+func TestSelectMVS(t *testing.T) {
+	available := []*Version{
+		MustParse("1.5.0"),
+		nil,
+		MustParse("1.2.0"),
+		MustParse("2.0.0"),
+		MustParse("1.3.1"),
+		MustParse("1.3.0"),
+	}
+
+	tests := []struct {
+		constraints []string
+		expected    string
+	}{
+		{[]string{">=1.3"}, "1.3.0"},
+		{[]string{">=1.3", "!=1.3.0"}, "1.3.1"},
+		{[]string{">=1.0", "<2"}, "1.2.0"},
+		{[]string{"^1.4"}, "1.5.0"},
+		{[]string{}, "1.2.0"},
+		{[]string{">=1.6", "<2"}, ""},
+	}
+
+	for _, tc := range tests {
+		cs := make([]*Constraints, len(tc.constraints))
+		for i, c := range tc.constraints {
+			var err error
+			cs[i], err = NewConstraint(c)
+			tt.AssertIsNotError(t, err)
+		}
+
+		got := SelectMVS(cs, available)
+		if tc.expected == "" {
+			if got != nil {
+				t.Errorf("SelectMVS(%v) = %s, expected nil", tc.constraints, got)
+			}
+			continue
+		}
+
+		if got == nil || got.String() != tc.expected {
+			t.Errorf("SelectMVS(%v) = %v, expected %s", tc.constraints, got, tc.expected)
+		}
+	}
+
+	tt.AssertIsNil(t, SelectMVS(nil, nil))
+}
+
 func TestConstraintsValidate(t *testing.T) {
 	tests := []struct {
 		constraint string
@@ -699,6 +1024,292 @@ func TestConstraintsValidate(t *testing.T) {
 	}
 }
 
+func TestConstraintsValidateByBranch(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   [][]string
+	}{
+		{"^1.0.0 || ^2.0.0", "3.0.0", [][]string{
+			{"3.0.0 does not have same major version as 1.0.0"},
+			{"3.0.0 does not have same major version as 2.0.0"},
+		}},
+		{"^1.0.0 || ^2.0.0", "2.1.0", [][]string{
+			{"2.1.0 does not have same major version as 1.0.0"},
+			nil,
+		}},
+		{">=1.0.0, <2.0.0 || =3.0.0-rc.1", "1.5.0-beta", [][]string{
+			{"1.5.0-beta is a prerelease version and the constraint is only looking for release versions"},
+			{"1.5.0-beta is not equal to 3.0.0-rc.1"},
+		}},
+		{">2.0.0, <1.0.0", "1.5.0", [][]string{
+			{"1.5.0 is less than or equal to 2.0.0", "1.5.0 is greater than or equal to 1.0.0"},
+		}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint+" "+tc.version, func(t *testing.T) {
+			branches := mustNewConstraint(tc.constraint).ValidateByBranch(MustParse(tc.version))
+			tt.AssertEqual(t, len(tc.expected), len(branches))
+
+			for i, errs := range branches {
+				var msgs []string
+				for _, err := range errs {
+					msgs = append(msgs, err.Error())
+				}
+				tt.AssertEqual(t, tc.expected[i], msgs)
+			}
+		})
+	}
+}
+
+func TestConstraintsCheckDetailed(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   CheckResult
+	}{
+		{"^1.2.3", "1.4.0", Satisfied},
+		{"^1.2.3", "2.0.0", Rejected},
+		{"^1.2.3", "1.4.0-rc.1", RejectedPrereleaseOnly},
+		{"^1.2.3", "2.1.0-rc.1", Rejected},
+		{">=1.2.3-0", "1.4.0-rc.1", Satisfied},
+		{"=1.2.3-*", "1.2.3-rc.1", Satisfied},
+		{">=1.0.0, <2.0.0 || >=3.0.0-0", "1.5.0-beta", RejectedPrereleaseOnly},
+		{">=1.0.0, <2.0.0 || >=3.0.0-0", "3.1.0-beta", Satisfied},
+		{">=1.0.0-0, <2.0.0", "1.5.0-beta", RejectedPrereleaseOnly},
+		{">=1.0.0-0, <1.2.0", "1.5.0-beta", Rejected},
+		{"<1.2.0 || ^1", "1.5.0-beta", RejectedPrereleaseOnly},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint+" "+tc.version, func(t *testing.T) {
+			c := mustNewConstraint(tc.constraint)
+			v := MustParse(tc.version)
+			tt.AssertEqual(t, tc.expected, c.CheckDetailed(v))
+
+			// Consistent with Validate and Check.
+			ok, _ := c.Validate(v)
+			tt.AssertEqual(t, ok, tc.expected == Satisfied)
+			tt.AssertEqual(t, c.Check(v), tc.expected != Rejected)
+		})
+	}
+}
+
+func TestExcludeVersions(t *testing.T) {
+	base, err := NewConstraint("^1.2.0 || ^2")
+	tt.AssertIsNotError(t, err)
+
+	c := ExcludeVersions(base, []*Version{MustParse("1.2.3"), MustParse("v2.1.0-rc.1")})
+	tt.AssertEqual(t, "^1.2.0 !=1.2.3 !=2.1.0-rc.1 || ^2 !=1.2.3 !=2.1.0-rc.1", c.String())
+
+	tests := []struct {
+		version string
+		check   bool
+	}{
+		{"1.2.2", true},
+		{"1.2.3", false},
+		{"1.2.3+build", false},
+		{"1.2.4", true},
+		{"2.1.0-rc.1", false},
+		{"2.1.0-rc.2", true},
+		{"2.1.0", true},
+		{"3.0.0", false},
+	}
+
+	for _, tc := range tests {
+		if a := c.Check(MustParse(tc.version)); a != tc.check {
+			t.Errorf("Expected check of %q to be %t", tc.version, tc.check)
+		}
+	}
+
+	// base is not modified
+	tt.AssertEqual(t, "^1.2.0 || ^2", base.String())
+	tt.AssertTrue(t, base.Check(MustParse("1.2.3")))
+
+	_, err = NewConstraint(c.String())
+	tt.AssertIsNotError(t, err)
+
+	// Nil versions are skipped and a nil base only excludes.
+	c = ExcludeVersions(nil, []*Version{nil, MustParse("1.2.3")})
+	tt.AssertEqual(t, "!=1.2.3", c.String())
+	tt.AssertTrue(t, c.Check(MustParse("1.2.4")))
+	tt.AssertFalse(t, c.Check(MustParse("1.2.3")))
+}
+
+func TestConstraintsDedup(t *testing.T) {
+	tests := []struct {
+		constraint string
+		want       string
+	}{
+		{">=1.0.0 >=1.0.0 <2.0.0", ">=1.0.0 <2.0.0"},
+		{">=1.0.0, <2.0.0, >= 1.0.0, <2.0.0", ">=1.0.0 <2.0.0"},
+		{">=1.0.0 >=1.0 <2.0.0", ">=1.0.0 >=1.0 <2.0.0"},
+		{"^1.2 ^1.2 || ^1.2", "^1.2 || ^1.2"},
+		{"!=1.2.3 >1 !=1.2.3", "!=1.2.3 >1"},
+	}
+
+	versions := []string{"0.9.0", "1.0.0", "1.2.3", "1.5.0", "2.0.0", "2.0.0-beta"}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := NewConstraint(tc.constraint)
+			tt.AssertIsNotError(t, err)
+
+			d := c.Dedup()
+			tt.AssertEqual(t, tc.want, d.String())
+
+			for _, s := range versions {
+				v := MustParse(s)
+				tt.AssertEqual(t, c.Check(v), d.Check(v))
+			}
+		})
+	}
+}
+
+func TestConstraintsSorted(t *testing.T) {
+	tests := []struct {
+		constraints []string
+		want        string
+	}{
+		{[]string{"<2.0.0 >=1.0.0", ">=1.0.0, <2.0.0"}, "<2.0.0 >=1.0.0"},
+		{[]string{"^2 || ^1", "^1 || ^2"}, "^1 || ^2"},
+		{[]string{">=1.10 >=1.9", ">=1.9 >=1.10"}, ">=1.9 >=1.10"},
+		{[]string{"!=1.2.3 !=1.2.2 >1 || <0.5", "<0.5 || >1 !=1.2.2 !=1.2.3"}, "!=1.2.2 !=1.2.3 >1 || <0.5"},
+		{[]string{"1.x 1", "1 1.x"}, "1 1.x"},
+	}
+
+	for _, tc := range tests {
+		for _, s := range tc.constraints {
+			t.Run(s, func(t *testing.T) {
+				c, err := NewConstraint(s)
+				tt.AssertIsNotError(t, err)
+
+				sorted := c.Sorted()
+				tt.AssertEqual(t, tc.want, sorted.String())
+				tt.AssertEqual(t, c.Check(MustParse("1.2.3")), sorted.Check(MustParse("1.2.3")))
+			})
+		}
+	}
+}
+
+func TestConstraintsIsPinned(t *testing.T) {
+	tests := []struct {
+		constraint string
+		pinned     string
+	}{
+		{"=1.2.3", "1.2.3"},
+		{"= v1.2.3-beta+meta", "1.2.3-beta+meta"},
+		{"=1.2", "1.2"},
+		{"1.2.3", ""},
+		{"^1.2.3", ""},
+		{"~1.2.3", ""},
+		{">=1.2.3", ""},
+		{"=1.x", ""},
+		{"=1.2.3-*", ""},
+		{"=1.2.3, <2", ""},
+		{"=1.2.3 || =1.2.4", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			c, err := NewConstraint(tc.constraint)
+			tt.AssertIsNotError(t, err)
+
+			v, ok := c.IsPinned()
+			tt.AssertEqual(t, tc.pinned != "", ok)
+			tt.AssertEqual(t, ok, c.IsExact())
+			if ok {
+				tt.AssertEqual(t, tc.pinned, v.String())
+			} else {
+				tt.AssertIsNil(t, v)
+			}
+		})
+	}
+}
+
+func TestConstraintsCheckExact(t *testing.T) {
+	versions := []string{"1.2.3", "v1.2.3", "1.2.3.0", "1.2", "1.2.3+meta", "1.2.3-beta", "1.2.3-beta+meta", "1.2.4"}
+
+	for _, constraint := range []string{"=1.2.3", "=1.2", "=1.2.3-beta", "=1.2.3+meta", "=1.2.3-beta+meta"} {
+		c := mustNewConstraint(constraint)
+		tt.AssertIsNotNil(t, c.exact)
+
+		general := *c
+		general.exact = nil
+
+		for _, s := range versions {
+			v := MustParse(s)
+			if c.Check(v) != general.Check(v) {
+				t.Errorf("Fast path for %q disagrees on %q", constraint, s)
+			}
+		}
+	}
+}
+
+func TestConstraintsAllowsPrereleases(t *testing.T) {
+	tests := []struct {
+		constraint string
+		expected   bool
+	}{
+		{"*", false},
+		{"^1.2.3", false},
+		{">=1.2.3+build", false},
+		{">=1.2.3-rc.1", true},
+		{"^1.0.0 || ~2.0.0-beta", true},
+		{">=1.0.0-0, <2.0.0", true},
+		{"=1.2.3-*", true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.constraint, func(t *testing.T) {
+			tt.AssertEqual(t, tc.expected, mustNewConstraint(tc.constraint).AllowsPrereleases())
+		})
+	}
+}
+
+func TestConstraintsFilterWithReasons(t *testing.T) {
+	c, err := NewConstraint(">=1.1, <2, !=1.2.3")
+	tt.AssertIsNotError(t, err)
+
+	v100 := MustParse("1.0.0")
+	v115 := MustParse("1.1.5")
+	v123 := MustParse("1.2.3")
+	v130 := MustParse("1.3.0")
+	v140 := MustParse("1.4.0-beta")
+	v200 := MustParse("2.0.0")
+
+	matched, rejected := c.FilterWithReasons([]*Version{v100, v115, v123, v130, v140, v200})
+	tt.AssertEqual(t, []*Version{v115, v130}, matched)
+	tt.AssertEqual(t, 4, len(rejected))
+
+	reasons := func(v *Version) []string {
+		var s []string
+		for _, err := range rejected[v] {
+			s = append(s, err.Error())
+		}
+		return s
+	}
+
+	tt.AssertEqual(t, []string{"1.0.0 is less than 1.1"}, reasons(v100))
+	tt.AssertEqual(t, []string{"1.2.3 is equal to 1.2.3"}, reasons(v123))
+	tt.AssertEqual(t, []string{"1.4.0-beta is a prerelease version and the constraint is only looking for release versions"}, reasons(v140))
+	tt.AssertEqual(t, []string{"2.0.0 is greater than or equal to 2"}, reasons(v200))
+}
+
+func TestConstraintsAdditionalMatchesIfPrerelease(t *testing.T) {
+	c, err := NewConstraint(">=1.1, <2 || >=3.0.0-0")
+	tt.AssertIsNotError(t, err)
+
+	var vs []*Version
+	for _, s := range []string{"1.0.0-beta", "1.1.0", "1.2.0-beta", "1.5.0-rc.1", "2.0.0-rc.1", "3.0.0-rc.1", "3.1.0"} {
+		vs = append(vs, MustParse(s))
+	}
+
+	got := c.AdditionalMatchesIfPrerelease(vs)
+	tt.AssertEqual(t, []*Version{vs[2], vs[3], vs[4]}, got)
+}
+
 func TestConstraintString(t *testing.T) {
 	tests := []struct {
 		constraint string
@@ -817,3 +1428,244 @@ func TestTextUnmarshalConstraints(t *testing.T) {
 		}
 	}
 }
+
+func TestNewConstraintFromSlice(t *testing.T) {
+	c, err := NewConstraintFromSlice([]string{">=1.2.3", "<2.0.0"})
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, ">=1.2.3 <2.0.0", c.String())
+	tt.AssertTrue(t, c.Check(MustParse("1.5.0")))
+	tt.AssertFalse(t, c.Check(MustParse("2.0.0")))
+
+	_, err = NewConstraintFromSlice([]string{">=1.2.3", "^1 || ^2"})
+	tt.AssertIsError(t, err)
+
+	_, err = NewConstraintFromSlice([]string{">= bar"})
+	tt.AssertIsError(t, err)
+}
+
+func TestJSONUnmarshalConstraints(t *testing.T) {
+	tests := []struct {
+		json string
+		want string
+	}{
+		{`">=1.2.3, <2.0.0"`, ">=1.2.3 <2.0.0"},
+		{`[">=1.2.3", "<2.0.0"]`, ">=1.2.3 <2.0.0"},
+		{` ["^1.2"]`, "^1.2"},
+		{`"^1 || ^2"`, "^1 || ^2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.json, func(t *testing.T) {
+			var cs Constraints
+			tt.AssertIsNotError(t, json.Unmarshal([]byte(tc.json), &cs))
+			tt.AssertEqual(t, tc.want, cs.String())
+		})
+	}
+
+	for _, s := range []string{`[">= bar"]`, `[1, 2]`, `["^1 || ^2"]`, `">= bar"`, `{}`} {
+		t.Run(s, func(t *testing.T) {
+			var cs Constraints
+			tt.AssertIsError(t, json.Unmarshal([]byte(s), &cs))
+		})
+	}
+
+	t.Run("null", func(t *testing.T) {
+		var s struct {
+			C Constraints
+		}
+		c, err := NewConstraint("^1.2")
+		tt.AssertIsNotError(t, err)
+		s.C = *c
+
+		tt.AssertIsNotError(t, json.Unmarshal([]byte(`{"C": null}`), &s))
+		tt.AssertEqual(t, "^1.2", s.C.String())
+
+		tt.AssertIsNotError(t, c.UnmarshalJSON([]byte(" null ")))
+		tt.AssertEqual(t, "^1.2", c.String())
+	})
+}
+
+func TestAsRangeConstraint(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		in      []string
+		out     []string
+	}{
+		{"1", ">=1.0.0 <2.0.0", []string{"1.0.0", "1.9.9"}, []string{"0.9.9", "2.0.0"}},
+		{"v1.2", ">=1.2.0 <1.3.0", []string{"1.2.0", "1.2.9"}, []string{"1.1.9", "1.3.0"}},
+		{"1.2-beta", ">=1.2.0-beta <1.3.0", []string{"1.2.0-beta", "1.2.1"}, []string{"1.2.0-alpha", "1.3.0"}},
+		{"1.2.3", "=1.2.3", []string{"1.2.3", "1.2.3+meta"}, []string{"1.2.4", "1.2.2"}},
+		{"1.2.3.4", "=1.2.3.4", []string{"1.2.3.4"}, []string{"1.2.3", "1.2.3.5"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			cs := MustParse(tc.version).AsRangeConstraint()
+			tt.AssertEqual(t, tc.want, cs.String())
+
+			for _, v := range tc.in {
+				if !cs.Check(MustParse(v)) {
+					t.Errorf("expected %q to satisfy %q", v, cs)
+				}
+			}
+			for _, v := range tc.out {
+				if cs.Check(MustParse(v)) {
+					t.Errorf("expected %q not to satisfy %q", v, cs)
+				}
+			}
+		})
+	}
+}
+
+func TestDriftConstraint(t *testing.T) {
+	tests := []struct {
+		version string
+		drift   [3]uint64
+		want    string
+		in      []string
+		out     []string
+	}{
+		{"1.2.3", [3]uint64{0, 2, 0}, ">=1.2.3 <1.5.0", []string{"1.2.3", "1.4.9"}, []string{"1.2.2", "1.5.0"}},
+		{"v1.2.3", [3]uint64{1, 0, 0}, ">=1.2.3 <3.0.0", []string{"1.3.0", "2.9.9"}, []string{"1.2.2", "3.0.0"}},
+		{"1.2.3", [3]uint64{0, 0, 2}, ">=1.2.3 <1.2.6", []string{"1.2.3", "1.2.5"}, []string{"1.2.2", "1.2.6", "1.3.0"}},
+		{"1.2.3", [3]uint64{0, 1, 5}, ">=1.2.3 <1.4.0", []string{"1.3.9"}, []string{"1.4.0"}},
+		{"1.2.3", [3]uint64{}, ">=1.2.3 <1.2.4", []string{"1.2.3"}, []string{"1.2.4"}},
+		{"1.2", [3]uint64{0, 1, 0}, ">=1.2.0 <1.4.0", []string{"1.2.0", "1.3.5"}, []string{"1.4.0"}},
+		{"1.2.3-beta+meta", [3]uint64{0, 0, 1}, ">=1.2.3-beta <1.2.5", []string{"1.2.3-beta", "1.2.4"}, []string{"1.2.3-alpha", "1.2.5"}},
+		{"1.2.3.4", [3]uint64{0, 1, 0}, ">=1.2.3.4 <1.4.0", []string{"1.2.3.4", "1.3.0"}, []string{"1.2.3.3", "1.4.0"}},
+		{"1.2.3", [3]uint64{math.MaxUint64, 0, 0}, ">=1.2.3", []string{"1.2.3", "99.0.0"}, []string{"1.2.2"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			cs := MustParse(tc.version).DriftConstraint(tc.drift[0], tc.drift[1], tc.drift[2])
+			tt.AssertEqual(t, tc.want, cs.String())
+
+			for _, v := range tc.in {
+				if !cs.Check(MustParse(v)) {
+					t.Errorf("expected %q to satisfy %q", v, cs)
+				}
+			}
+			for _, v := range tc.out {
+				if cs.Check(MustParse(v)) {
+					t.Errorf("expected %q not to satisfy %q", v, cs)
+				}
+			}
+		})
+	}
+}
+
+func TestSuggestConstraint(t *testing.T) {
+	tests := []struct {
+		version string
+		style   string
+		want    string
+	}{
+		{"1.2.3", "exact", "=1.2.3"},
+		{"1.2.3", "caret", "^1.2.3"},
+		{"1.2.3", "tilde", "~1.2.3"},
+		{"1.2.3", "minor", "1.2.x"},
+		{"v1.2.3-rc.1+build", "exact", "=1.2.3-rc.1"},
+		{"v1.2.3-rc.1+build", "caret", "^1.2.3-rc.1"},
+		{"v1.2.3-rc.1+build", "minor", "1.2.x"},
+		{"1", "minor", "1.0.x"},
+		{"1.2.3.4", "tilde", "~1.2.3.4"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version+" "+tc.style, func(t *testing.T) {
+			v := MustParse(tc.version)
+			c := v.SuggestConstraint(tc.style)
+			tt.AssertIsNotNil(t, c)
+			tt.AssertEqual(t, tc.want, c.String())
+			tt.AssertTrue(t, c.Check(v))
+		})
+	}
+
+	tt.AssertIsNil(t, MustParse("1.2.3").SuggestConstraint("latest"))
+}
+
+func TestTightestCaret(t *testing.T) {
+	tests := []struct {
+		versions []string
+		expected string
+		err      string
+	}{
+		{[]string{"1.4.0", "1.2.3", "1.9.1"}, "^1.2.3", ""},
+		{[]string{"1.2.3"}, "^1.2.3", ""},
+		{[]string{"1.2.3+build", "1.3.0-rc.1"}, "^1.2.3", ""},
+		{[]string{"1.3.0", "1.3.0-rc.1"}, "^1.3.0-rc.1", ""},
+		{[]string{"0.2.3", "0.2.9"}, "^0.2.3", ""},
+		{[]string{"0.2.3", "0.3.1"}, "^0", ""},
+		{[]string{"0.0.3", "0.0.5"}, "^0.0", ""},
+		{[]string{"v1.2", "1.5.0"}, "^1.2", ""},
+		{[]string{"1.2.3", "2.0.0"}, "", "versions span multiple major versions (1.2.3 and 2.0.0), no caret constraint covers them"},
+		{[]string{"0.9.0", "1.0.0"}, "", "versions span multiple major versions (0.9.0 and 1.0.0), no caret constraint covers them"},
+		{nil, "", "no versions to build a caret constraint from"},
+	}
+
+	for _, tc := range tests {
+		t.Run(strings.Join(tc.versions, " "), func(t *testing.T) {
+			vs := make([]*Version, len(tc.versions))
+			for i, s := range tc.versions {
+				vs[i] = MustParse(s)
+			}
+
+			c, err := TightestCaret(vs)
+			if tc.err != "" {
+				tt.AssertIsError(t, err)
+				tt.AssertEqual(t, tc.err, err.Error())
+				return
+			}
+
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.expected, c.String())
+		})
+	}
+}
+
+func TestConstraintParseError(t *testing.T) {
+	tests := []struct {
+		constraint string
+		offset     int
+		token      string
+	}{
+		{">= bar", 0, ">= bar"},
+		{"1.2.3 foo", 6, "foo"},
+		{">=1.0 && <2.0 || 3.0 bad", 21, "bad"},
+		{"1.0 - 2.0, >= bar", 11, ">= bar"},
+		{"^1 || 1.2.3 bad", 12, "bad"},
+		{"1.2.3-bad bad", 10, "bad"},
+		{">=1.2.3, ", 7, ","},
+		{" ,1.2.3", 1, ","},
+		{">=1.2.3, 1.x.3", 9, "1.x.3"},
+		{"^1 || ^1.2.3-*", 6, "^1.2.3-*"},
+		{">=1.2.3 &&", 8, "&&"},
+		{"&& >=1.0.0", 0, "&&"},
+		{">=1.2.3 && && <2", 11, "&&"},
+		{">=1.2.3, && <2", 9, "&&"},
+		{"1.0 - 2.0 && >= bar", 13, ">= bar"},
+		{"^1 || >=1 && ^1.2.3-*", 13, "^1.2.3-*"},
+	}
+
+	for _, tc := range tests {
+		_, err := NewConstraint(tc.constraint)
+
+		var perr *ConstraintParseError
+		if !errors.As(err, &perr) {
+			t.Errorf("NewConstraint(%q) returned %v, expected a *ConstraintParseError", tc.constraint, err)
+			continue
+		}
+
+		tt.AssertEqual(t, tc.constraint, perr.Input)
+		tt.AssertEqual(t, tc.offset, perr.Offset)
+		tt.AssertEqual(t, tc.token, perr.Token)
+		tt.AssertEqual(t, tc.token, tc.constraint[perr.Offset:perr.Offset+len(perr.Token)])
+		tt.AssertTrue(t, strings.Contains(tc.constraint, perr.Constraint))
+	}
+
+	// The rejected group is reported as written, not as rewritten.
+	_, err := NewConstraint("^1 || 1.0 - 2.0 && && <3")
+	tt.AssertEqual(t, "improper constraint:  1.0 - 2.0 && && <3", err.Error())
+}
@@ -5,8 +5,12 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var (
@@ -30,6 +34,14 @@ var (
 
 	// ErrInvalidPrerelease is returned when the pre-release is an invalid format
 	ErrInvalidPrerelease = errors.New("invalid Prerelease string")
+
+	// ErrTooManyParts is returned when a version has more numeric parts than
+	// allowed.
+	ErrTooManyParts = errors.New("too many version parts")
+
+	// ErrPartOverflow is returned when increasing a version part would
+	// overflow it.
+	ErrPartOverflow = errors.New("version part overflow")
 )
 
 // Version represents a single semantic version.
@@ -38,6 +50,14 @@ type Version struct {
 	pre      string
 	metadata string
 	original string
+
+	// prefix is the part of original before the version itself (e.g. v)
+	prefix string
+
+	// input is the parsed string when it is not a valid version by itself
+	// (e.g. 1,2,3), so that Original() returns it while original stays
+	// parsable. It is empty otherwise.
+	input string
 }
 
 const num string = "0123456789"
@@ -59,22 +79,8 @@ func StrictNewVersion(v string) (*Version, error) {
 		original: v,
 	}
 
-	var partsString = v
-	if i := strings.Index(v, "-"); i != -1 { // v1.2.3-some or v1.2.3-some+123
-		partsString = v[:i] // [v1.2.3]
-		sv.pre = v[i+1:]    // [some] or [some+123]
-	} else { // v1.2.3 or v1.2.3+123
-		if i := strings.Index(v, "+"); i != -1 { // v1.2.3+123
-			partsString = v[:i]   // [v1.2.3]
-			sv.metadata = v[i+1:] // [123]
-		}
-	}
-	if sv.pre != "" { // v:(v1.2.3-some or v1.2.3-some+123) pre:(some or some+123)
-		if i := strings.Index(sv.pre, "+"); i != -1 { // pre: some+123
-			sv.metadata = sv.pre[i+1:]
-			sv.pre = sv.pre[:i]
-		}
-	}
+	var partsString string
+	partsString, sv.pre, sv.metadata = splitVersion(v)
 
 	// Split the parts
 	parts := strings.Split(partsString, ".")
@@ -117,18 +123,169 @@ func StrictNewVersion(v string) (*Version, error) {
 	return sv, nil
 }
 
+// splitVersion splits a version into its numeric parts, prerelease and
+// metadata strings without validating them.
+//
+// The prerelease starts at the first `-`, even if it follows a `+`, so a
+// hyphen in the metadata (e.g. 1.2.3+build-5) ends up in the parts and is
+// rejected by StrictNewVersion.
+func splitVersion(v string) (parts, pre, metadata string) {
+	parts = v
+	if i := strings.Index(v, "-"); i != -1 { // v1.2.3-some or v1.2.3-some+123
+		parts = v[:i] // [v1.2.3]
+		pre = v[i+1:] // [some] or [some+123]
+	} else { // v1.2.3 or v1.2.3+123
+		if i := strings.Index(v, "+"); i != -1 { // v1.2.3+123
+			parts = v[:i]      // [v1.2.3]
+			metadata = v[i+1:] // [123]
+		}
+	}
+	if pre != "" { // v:(v1.2.3-some or v1.2.3-some+123) pre:(some or some+123)
+		if i := strings.Index(pre, "+"); i != -1 { // pre: some+123
+			metadata = pre[i+1:]
+			pre = pre[:i]
+		}
+	}
+
+	return parts, pre, metadata
+}
+
+// SplitVersion splits a version string into its pieces without validating
+// them, which is useful to inspect malformed versions.
+//
+// prefix is everything before the first digit (e.g. `v` or `release-`), core
+// is the dot separated numbers, prerelease is what follows the first `-` and
+// metadata what follows the first `+`. The separators are not included.
+//
+// The string is split the same way StrictNewVersion does, so a hyphen after
+// the `+` starts the prerelease: 1.2.3+build-5 gives the core 1.2.3+build and
+// the prerelease 5, showing why it is rejected.
+func SplitVersion(s string) (core string, prerelease string, metadata string, prefix string) {
+	i := strings.IndexAny(s, num)
+	if i == -1 {
+		i = len(s)
+	}
+	prefix = s[:i]
+
+	core, prerelease, metadata = splitVersion(s[i:])
+	return core, prerelease, metadata, prefix
+}
+
 // NewVersion parses a given version and returns an instance of Version or
 // an error if unable to parse the version.
 // This version allow a `v` prefix
 func NewVersion(v string) (sv *Version, err error) {
-	sv, err = StrictNewVersion(strings.TrimPrefix(v, "v"))
+	return newVersionWithPrefix(v, "v")
+}
+
+// newVersionWithPrefix strictly parses v after removing the optional prefix,
+// which is kept in the original.
+func newVersionWithPrefix(v string, prefix string) (*Version, error) {
+	if !strings.HasPrefix(v, prefix) {
+		prefix = ""
+	}
+
+	sv, err := StrictNewVersion(v[len(prefix):])
 	if err != nil {
-		return
+		return nil, err
 	}
 
 	sv.original = v
+	sv.prefix = prefix
+
+	return sv, nil
+}
+
+// NewVersionWithPrefixes parses a given version after removing the first of
+// the given prefixes it starts with, the longest prefix wins when several
+// match (e.g. `version` over `v` for version1.2.3). The prefix is kept in the
+// original and in versions derived from it, but not in String().
+//
+// Unlike NewVersion, a `v` prefix is only allowed if listed.
+func NewVersionWithPrefixes(v string, prefixes ...string) (*Version, error) {
+	prefix := ""
+	for _, p := range prefixes {
+		if len(p) > len(prefix) && strings.HasPrefix(v, p) {
+			prefix = p
+		}
+	}
+
+	return newVersionWithPrefix(v, prefix)
+}
+
+// NewVersionMaxParts parses a given version like NewVersion but returns
+// ErrTooManyParts if it has more than maxParts numeric parts.
+func NewVersionMaxParts(v string, maxParts int) (*Version, error) {
+	sv, err := NewVersion(v)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sv.parts) > maxParts {
+		return nil, ErrTooManyParts
+	}
 
-	return
+	return sv, nil
+}
+
+// NewVersionTrimDots parses a given version like NewVersion but tolerates
+// leading and trailing dots (e.g. `1.2.3.` or `.1.2`) by trimming them before
+// parsing. The trimmed string is kept as the original.
+func NewVersionTrimDots(v string) (*Version, error) {
+	return NewVersion(strings.Trim(v, "."))
+}
+
+// NewVersionWithFixup parses a given version like NewVersion. If that fails,
+// it is parsed again after being transformed by fixup, which lets callers
+// salvage invalid input their own way (e.g. by stripping a suffix). The fixed
+// up string is kept as the original.
+//
+// When the fixed up version can't be parsed either, the error of the first
+// attempt is returned.
+func NewVersionWithFixup(v string, fixup func(string) string) (*Version, error) {
+	sv, err := NewVersion(v)
+	if err == nil || fixup == nil {
+		return sv, err
+	}
+
+	if fixed, fixupErr := NewVersion(fixup(v)); fixupErr == nil {
+		return fixed, nil
+	}
+
+	return nil, err
+}
+
+// NewVersionCommaSeparated parses a given version like NewVersion but accepts
+// commas in place of the dots between the numeric parts (e.g. `1,2,3`), as
+// found in some locale affected spreadsheet exports. Commas in the prerelease
+// or metadata are not replaced and are still rejected.
+//
+// Like NewVersion, the numeric parts may be fewer than three and prefixed
+// with a `v`, so `v1,2` is accepted as well.
+//
+// Original() returns the input with its commas, while String(), marshaling
+// and versions derived from it use dots, so they can be parsed again.
+func NewVersionCommaSeparated(v string) (*Version, error) {
+	core := v
+	if i := strings.IndexAny(core, "-+"); i != -1 {
+		core = core[:i]
+	}
+
+	sv, err := NewVersion(strings.Replace(core, ",", ".", -1) + v[len(core):])
+	if err != nil {
+		return nil, err
+	}
+
+	sv.input = v
+	return sv, nil
+}
+
+// ParseNoPrefix parses a given version and returns an instance of Version or
+// an error if unable to parse the version. Unlike NewVersion, any `v` or `V`
+// prefix is rejected with ErrInvalidCharacters.
+// It is the same as StrictNewVersion.
+func ParseNoPrefix(v string) (*Version, error) {
+	return StrictNewVersion(v)
 }
 
 func NewVersionByParts(nums ...uint64) *Version {
@@ -138,6 +295,42 @@ func NewVersionByParts(nums ...uint64) *Version {
 	}
 }
 
+// CalVerLayout selects the parts of a version created by NewCalVerWithLayout.
+type CalVerLayout int
+
+const (
+	// CalVerYearMonthMicro creates YYYY.MM.micro versions, e.g. 2023.4.2.
+	CalVerYearMonthMicro CalVerLayout = iota
+
+	// CalVerYearMonthDay creates YYYY.MM.DD versions, e.g. 2023.4.27. A non-zero
+	// micro is added as a fourth part, e.g. 2023.4.27.1.
+	CalVerYearMonthDay
+)
+
+// NewCalVer creates a YYYY.MM.micro calendar version from t.
+// Same as NewCalVerWithLayout(CalVerYearMonthMicro, t, micro)
+func NewCalVer(t time.Time, micro uint64) *Version {
+	return NewCalVerWithLayout(CalVerYearMonthMicro, t, micro)
+}
+
+// NewCalVerWithLayout creates a calendar version from t using the given
+// layout. The year is the first part and the month (1-12) is the second, the
+// following parts depend on the layout. Months and days are not zero padded
+// as leading zeros are not allowed in versions.
+func NewCalVerWithLayout(layout CalVerLayout, t time.Time, micro uint64) *Version {
+	year, month, day := t.Date()
+
+	switch layout {
+	case CalVerYearMonthDay:
+		if micro == 0 {
+			return NewVersionByParts(uint64(year), uint64(month), uint64(day))
+		}
+		return NewVersionByParts(uint64(year), uint64(month), uint64(day), micro)
+	default:
+		return NewVersionByParts(uint64(year), uint64(month), micro)
+	}
+}
+
 func joinNumbers(nums []uint64) string {
 	b := strings.Builder{}
 	for i, n := range nums {
@@ -149,6 +342,68 @@ func joinNumbers(nums []uint64) string {
 	return b.String()
 }
 
+// ParseEnvVersion parses a version from environment style KEY=value input,
+// e.g. `VERSION=1.2.3`. The key must equal key, surrounding whitespace is
+// ignored and the value may be wrapped in single or double quotes.
+func ParseEnvVersion(kv string, key string) (*Version, error) {
+	i := strings.Index(kv, "=")
+	if i == -1 {
+		return nil, fmt.Errorf("expected %s=value, got %q", key, kv)
+	}
+
+	k, value := strings.TrimSpace(kv[:i]), strings.TrimSpace(kv[i+1:])
+	if k != key {
+		return nil, fmt.Errorf("unexpected key %q, expected %q", k, key)
+	}
+
+	if len(value) >= 2 {
+		if q := value[0]; (q == '"' || q == '\'') && value[len(value)-1] == q {
+			value = value[1 : len(value)-1]
+		}
+	}
+
+	v, err := NewVersion(value)
+	if err != nil {
+		return nil, wrapInvalidVersion(value, err)
+	}
+
+	return v, nil
+}
+
+// versionCache holds the versions parsed by NewVersionCached by their input.
+var versionCache sync.Map
+
+// NewVersionCached parses a given version like NewVersion, but keeps the
+// result in a cache shared by all goroutines so parsing the same string again
+// returns the same instance. Invalid versions are not cached.
+//
+// As the instances are shared they must not be modified, for example by
+// unmarshaling into them, versions derived from them are copies and are safe
+// to modify. Every distinct valid input stays in memory until
+// ClearVersionCache is called, so it should only be used for a bounded set of
+// inputs.
+func NewVersionCached(v string) (*Version, error) {
+	if sv, ok := versionCache.Load(v); ok {
+		return sv.(*Version), nil
+	}
+
+	sv, err := NewVersion(v)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, _ := versionCache.LoadOrStore(v, sv)
+	return cached.(*Version), nil
+}
+
+// ClearVersionCache removes all the versions cached by NewVersionCached.
+func ClearVersionCache() {
+	versionCache.Range(func(key, _ interface{}) bool {
+		versionCache.Delete(key)
+		return true
+	})
+}
+
 // MustParse parses a given version and panics on error.
 func MustParse(v string) *Version {
 	sv, err := NewVersion(v)
@@ -164,16 +419,117 @@ func (v *Version) Copy() Version {
 		pre:      v.pre,
 		metadata: v.metadata,
 		original: v.original,
+		prefix:   v.prefix,
+		input:    v.input,
 	}
 }
 
+// Rebuild returns a copy of the version with the original regenerated from
+// its parts, prerelease and metadata, keeping the prefix. It makes Original()
+// and String() consistent again after the fields were changed directly.
+func (v *Version) Rebuild() Version {
+	vNext := v.Copy()
+	vNext.updateOriginal()
+	return vNext
+}
+
 // String converts a Version object to a string.
-// Note, if the original version contained a leading v this version will not.
+// Note, if the original version contained a leading v (or any other prefix
+// allowed by NewVersionWithPrefixes) this version will not.
 // See the Original() method to retrieve the original value. Semantic Versions
 // don't contain a leading v per the spec. Instead it's optional on
 // implementation.
 func (v *Version) String() string {
-	return strings.TrimPrefix(v.original, "v")
+	return strings.TrimPrefix(v.original, v.prefix)
+}
+
+// Map returns the fields of the version keyed by name, for use as a
+// text/template context: Major, Minor, Patch (uint64), Prerelease, Metadata,
+// Original and String (string).
+func (v *Version) Map() map[string]interface{} {
+	return map[string]interface{}{
+		"Major":      v.Part(1),
+		"Minor":      v.Minor(),
+		"Patch":      v.Patch(),
+		"Prerelease": v.pre,
+		"Metadata":   v.metadata,
+		"Original":   v.Original(),
+		"String":     v.String(),
+	}
+}
+
+// Components are the SemVer fields of a version, for example to be returned
+// from an API. Build is the metadata of the version.
+type Components struct {
+	Major      uint64 `json:"major"`
+	Minor      uint64 `json:"minor"`
+	Patch      uint64 `json:"patch"`
+	Prerelease string `json:"prerelease"`
+	Build      string `json:"build"`
+}
+
+// Components returns the SemVer fields of the version. Missing parts are 0 and
+// parts after the third one are not included.
+func (v *Version) Components() Components {
+	return Components{
+		Major:      v.Part(1),
+		Minor:      v.Minor(),
+		Patch:      v.Patch(),
+		Prerelease: v.pre,
+		Build:      v.metadata,
+	}
+}
+
+// Format returns the version formatted according to layout, in which the
+// following tokens are expanded:
+//   - %M the major version
+//   - %m the minor version
+//   - %p the patch version
+//   - %P the prerelease, without the leading -
+//   - %B the metadata, without the leading +
+//   - %% a literal %
+//
+// Missing parts are 0 and a missing prerelease or metadata is an empty
+// string, so "v%M.%m" gives v1.2 for 1.2.3 and "%M.%m.%p-%P" gives 1.0.0- for
+// 1. Any other character, including an unknown token, is kept as is.
+func (v *Version) Format(layout string) string {
+	b := strings.Builder{}
+
+	for i := 0; i < len(layout); i++ {
+		if layout[i] != '%' || i == len(layout)-1 {
+			b.WriteByte(layout[i])
+			continue
+		}
+
+		i++
+		switch layout[i] {
+		case 'M':
+			b.WriteString(strconv.FormatUint(v.Part(1), 10))
+		case 'm':
+			b.WriteString(strconv.FormatUint(v.Minor(), 10))
+		case 'p':
+			b.WriteString(strconv.FormatUint(v.Patch(), 10))
+		case 'P':
+			b.WriteString(v.pre)
+		case 'B':
+			b.WriteString(v.metadata)
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			b.WriteByte(layout[i])
+		}
+	}
+
+	return b.String()
+}
+
+// Debug returns a verbose representation of the internal state of the
+// version, like
+// Version{parts:[1 2 3], pre:"beta.1", metadata:"build.5", original:"v1.2.3-beta.1+build.5", prefix:"v"}
+func (v *Version) Debug() string {
+	return fmt.Sprintf("Version{parts:%v, pre:%q, metadata:%q, original:%q, prefix:%q}",
+		v.parts, v.pre, v.metadata, v.original, v.prefix)
 }
 
 func (v *Version) updateOriginal() {
@@ -203,10 +559,314 @@ func (v *Version) updateOriginal() {
 	}
 
 	v.original = buf.String()
+	v.input = ""
+}
+
+// PaddedString returns the version with every numeric part zero padded to
+// width digits (e.g. 00001.00002.00003 for 1.2.3 and width 5), so versions can
+// be ordered by plain string comparison.
+//
+// Numeric prerelease identifiers are padded as well and the identifiers are
+// separated by a `,`, which sorts before any character of an identifier, so
+// 1.2.3-alpha.1 (00001.00002.00003-alpha,00001 for width 5) sorts before
+// 1.2.3-alpha-x. Releases end with a `~` sentinel which sorts after the `-` of
+// any prerelease, so 1.2.3-beta sorts before 1.2.3. Metadata is dropped as it
+// does not affect the order.
+//
+// The ordering is only correct for versions with the same number of parts and
+// as long as no number has more than width digits, longer numbers are not
+// truncated but will sort incorrectly. Alphanumeric identifiers starting with
+// a `-`, like in 1.2.3--beta, sort before the numeric ones and are not
+// supported either.
+func (v *Version) PaddedString(width int) string {
+	b := strings.Builder{}
+
+	for i, p := range v.parts {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		writePadded(&b, strconv.FormatUint(p, 10), width)
+	}
+
+	if v.pre == "" {
+		b.WriteByte('~')
+		return b.String()
+	}
+
+	b.WriteByte('-')
+	for i, p := range strings.Split(v.pre, ".") {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		if p != "" && containsOnly(p, num) {
+			writePadded(&b, p, width)
+		} else {
+			b.WriteString(p)
+		}
+	}
+
+	return b.String()
+}
+
+func writePadded(b *strings.Builder, s string, width int) {
+	for i := len(s); i < width; i++ {
+		b.WriteByte('0')
+	}
+	b.WriteString(s)
+}
+
+// OrderedKey returns the major, minor and patch numbers and a rank of the
+// prerelease as a fixed-size array, usable as a comparable key, e.g. in an
+// ordered map or a B-tree. Comparing keys element by element orders them like
+// Compare, except that distinct prereleases may get the same rank.
+//
+// Parts after the patch are ignored, so the keys are only ordered like Compare
+// for versions with at most 3 parts: 1.2.3.1-alpha is greater than 1.2.3 but
+// gets a lower key, as its prerelease ranks before the release.
+//
+// The rank is math.MaxUint64 for a release, so it comes after its
+// prereleases. For a prerelease it only depends on the first identifier:
+//
+//   - a numeric identifier n ranks n, capped at 1<<63 - 1
+//   - an alphanumeric identifier ranks 1<<63 plus its first 7 bytes read as
+//     a big-endian number, so it comes after the numeric ones
+func (v *Version) OrderedKey() [4]uint64 {
+	key := [4]uint64{v.Part(1), v.Minor(), v.Patch(), math.MaxUint64}
+	if v.pre == "" {
+		return key
+	}
+
+	id := v.pre
+	if i := strings.IndexByte(id, '.'); i != -1 {
+		id = id[:i]
+	}
+
+	if n, err := strconv.ParseUint(id, 10, 64); err == nil {
+		if n > math.MaxInt64 {
+			n = math.MaxInt64
+		}
+		key[3] = n
+		return key
+	}
+
+	var packed uint64
+	for i := 0; i < 7; i++ {
+		packed <<= 8
+		if i < len(id) {
+			packed |= uint64(id[i])
+		}
+	}
+	key[3] = 1<<63 | packed
+
+	return key
+}
+
+// storageKeyWidth is the number of digits of the max uint64 value.
+const storageKeyWidth = 20
+
+// StorageKey returns a form of the version that sorts like Compare with a
+// byte-wise (C or binary) collation, e.g. for indexed range queries in a
+// database, and that can be decoded with ParseStorageKey. Locale aware
+// collations may order the keys differently.
+//
+// Numeric parts and numeric prerelease identifiers are zero padded to 20
+// digits. A release ends with a `.` which sorts after the `-` of its
+// prereleases and before any further part, and the metadata is kept at the
+// end after a `+`:
+//
+//	00000000000000000001.00000000000000000002.00000000000000000003.
+//	00000000000000000001.00000000000000000002.00000000000000000003-beta.00000000000000000002+build.5
+//
+// The order may be wrong for versions only differing by trailing zero parts
+// (1.2 sorts before 1.2.0-rc.1) and for prerelease identifiers extending
+// another one with a hyphen (beta.1 sorts after beta-x). The prefix of the
+// version is not kept.
+func (v *Version) StorageKey() string {
+	b := strings.Builder{}
+
+	for i, p := range v.parts {
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		writePadded(&b, strconv.FormatUint(p, 10), storageKeyWidth)
+	}
+
+	if v.pre == "" {
+		b.WriteByte('.')
+	} else {
+		b.WriteByte('-')
+		for i, p := range strings.Split(v.pre, ".") {
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			if p != "" && containsOnly(p, num) {
+				writePadded(&b, p, storageKeyWidth)
+			} else {
+				b.WriteString(p)
+			}
+		}
+	}
+
+	if v.metadata != "" {
+		b.WriteByte('+')
+		b.WriteString(v.metadata)
+	}
+
+	return b.String()
+}
+
+// ParseStorageKey decodes a version encoded with StorageKey.
+func ParseStorageKey(s string) (*Version, error) {
+	key, metadata := s, ""
+	if i := strings.IndexByte(key, '+'); i != -1 {
+		key, metadata = key[:i], key[i:]
+	}
+
+	var parts, pre string
+	if strings.HasSuffix(key, ".") {
+		parts = key[:len(key)-1]
+	} else if i := strings.IndexByte(key, '-'); i != -1 {
+		parts, pre = key[:i], key[i+1:]
+	} else {
+		return nil, fmt.Errorf("invalid storage key %q: missing release or prerelease marker", s)
+	}
+
+	b := strings.Builder{}
+	for i, p := range strings.Split(parts, ".") {
+		if len(p) != storageKeyWidth || !containsOnly(p, num) {
+			return nil, fmt.Errorf("invalid storage key %q: part %d is not a %d digit number", s, i+1, storageKeyWidth)
+		}
+		if i > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(trimLeadingZeros(p))
+	}
+
+	if pre != "" {
+		b.WriteByte('-')
+		for i, p := range strings.Split(pre, ".") {
+			if i > 0 {
+				b.WriteByte('.')
+			}
+			if p != "" && containsOnly(p, num) {
+				p = trimLeadingZeros(p)
+			}
+			b.WriteString(p)
+		}
+	}
+
+	v, err := StrictNewVersion(b.String() + metadata)
+	if err != nil {
+		return nil, fmt.Errorf("invalid storage key %q: %w", s, err)
+	}
+
+	return v, nil
+}
+
+// trimLeadingZeros removes the zero padding of a number, keeping a single 0.
+func trimLeadingZeros(s string) string {
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+// maxDockerTagLength is the max length of a Docker image tag.
+const maxDockerTagLength = 128
+
+// DockerTag returns the version as a Docker image tag. Tags can't contain a
+// `+`, so the one before the metadata is replaced by `_`, which can't appear
+// anywhere else in a version: 1.2.3-rc.1+build.5 gives 1.2.3-rc.1_build.5.
+// Use ParseDockerTag to get the version back.
+//
+// Like String(), the prefix of the version is not included. The tag is not
+// valid if it is longer than 128 characters.
+func (v *Version) DockerTag() string {
+	return strings.Replace(v.String(), "+", "_", 1)
+}
+
+// IsValidDockerTag reports whether String() can be used as a Docker image tag
+// as is, which is not the case when the version has metadata or is longer
+// than 128 characters. See DockerTag for a tag safe form.
+func (v *Version) IsValidDockerTag() bool {
+	s := v.String()
+	return s != "" && len(s) <= maxDockerTagLength && !strings.Contains(s, "+")
+}
+
+// ParseDockerTag parses a version from a tag created by DockerTag, the `_`
+// is turned back into the `+` before the metadata. A `v` prefix is allowed
+// like for NewVersion.
+func ParseDockerTag(tag string) (*Version, error) {
+	return NewVersion(strings.Replace(tag, "_", "+", 1))
+}
+
+// pep440Channels maps prerelease channels to their PEP 440 form.
+var pep440Channels = map[string]string{
+	"a":       "a",
+	"alpha":   "a",
+	"b":       "b",
+	"beta":    "b",
+	"c":       "rc",
+	"rc":      "rc",
+	"pre":     "rc",
+	"preview": "rc",
+	"dev":     ".dev",
+	"post":    ".post",
+}
+
+// ToEcosystem returns the version in the conventions of a package ecosystem:
+//   - "npm": String(), without a prefix
+//   - "go": String() with a `v` prefix, e.g. v1.2.3-rc.1
+//   - "python": PEP 440 like, prereleases made of a known channel and an
+//     optional number are mapped to their PEP 440 form (1.2.3-rc.1 gives
+//     1.2.3rc1, 1.2.3-alpha.2 gives 1.2.3a2 and 1.2.3-dev.4 gives
+//     1.2.3.dev4) and metadata becomes the local version (+build.5), other
+//     prereleases are kept as is
+//   - "docker": DockerTag()
+//
+// String() is returned for any other ecosystem.
+func (v *Version) ToEcosystem(eco string) string {
+	switch eco {
+	case "go":
+		return "v" + v.String()
+	case "python":
+		return v.pep440()
+	case "docker":
+		return v.DockerTag()
+	default:
+		return v.String()
+	}
+}
+
+func (v *Version) pep440() string {
+	s := joinNumbers(v.parts)
+
+	if v.pre != "" {
+		parts := strings.Split(v.pre, ".")
+		channel, ok := pep440Channels[strings.ToLower(parts[0])]
+		switch {
+		case ok && len(parts) == 1:
+			s += channel
+		case ok && len(parts) == 2 && containsOnly(parts[1], num):
+			s += channel + parts[1]
+		default:
+			s += "-" + v.pre
+		}
+	}
+
+	if v.metadata != "" {
+		s += "+" + v.metadata
+	}
+
+	return s
 }
 
 // Original returns the original value passed in to be parsed.
 func (v *Version) Original() string {
+	if v.input != "" {
+		return v.input
+	}
 	return v.original
 }
 
@@ -222,6 +882,18 @@ func (v *Version) Part(part int) uint64 {
 	return 0
 }
 
+// SignificantParts returns the number of the last non-zero part, so 1.2.0.0
+// reports 2 and could be displayed as 1.2. It returns 1 when all parts are zero.
+func (v *Version) SignificantParts() int {
+	for i := len(v.parts); i > 1; i-- {
+		if v.parts[i-1] != 0 {
+			return i
+		}
+	}
+
+	return 1
+}
+
 // Major returns the major version.
 func (v *Version) Major() uint64 {
 	return v.parts[0]
@@ -242,6 +914,135 @@ func (v *Version) Prerelease() string {
 	return v.pre
 }
 
+// PrereleaseParts returns the dot separated identifiers of the pre-release,
+// or nil if there is none. For 1.2.0-beta.42 it is ["beta", "42"].
+func (v *Version) PrereleaseParts() []string {
+	if v.pre == "" {
+		return nil
+	}
+
+	return strings.Split(v.pre, ".")
+}
+
+// PrereleaseNumber returns the trailing pre-release identifier as a number,
+// e.g. 42 for 1.2.0-beta.42. ok is false if there is no pre-release or its last
+// identifier is not numeric.
+func (v *Version) PrereleaseNumber() (n uint64, ok bool) {
+	parts := v.PrereleaseParts()
+	if len(parts) == 0 {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(parts[len(parts)-1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return n, true
+}
+
+// IsPrerelease reports whether the version has a pre-release.
+func (v *Version) IsPrerelease() bool {
+	return v.pre != ""
+}
+
+// IsDevelopment reports whether the version is a 0.y.z version, which SemVer
+// reserves for initial development where anything may change at any time.
+// Note that ^ only allows patch updates for 0.y.z versions with y > 0, and
+// nothing but the exact version for 0.0.z.
+func (v *Version) IsDevelopment() bool {
+	return v.Part(1) == 0
+}
+
+// IsPseudoVersion reports whether the version has the shape of a Go modules
+// pseudo-version, which refers to a commit rather than a tagged release:
+//
+//	v0.0.0-20230101000000-abcdef123456        (no earlier tag)
+//	v1.2.4-0.20230101000000-abcdef123456      (after the v1.2.3 release)
+//	v1.2.3-pre.0.20230101000000-abcdef123456  (after the v1.2.3-pre prerelease)
+//
+// Like the go command, only the shape is checked, not that the timestamp is a
+// valid time. Metadata such as +incompatible is allowed.
+func (v *Version) IsPseudoVersion() bool {
+	_, ok := v.pseudoVersionTimestamp()
+	return ok
+}
+
+// PseudoVersionTime returns the UTC commit time encoded in a pseudo-version,
+// see IsPseudoVersion. It returns false if the version is not a
+// pseudo-version or its timestamp is not a valid time.
+func (v *Version) PseudoVersionTime() (time.Time, bool) {
+	ts, ok := v.pseudoVersionTimestamp()
+	if !ok {
+		return time.Time{}, false
+	}
+
+	t, err := time.Parse(pseudoVersionTimeLayout, ts)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+const pseudoVersionTimeLayout = "20060102150405"
+
+// pseudoVersionTimestamp returns the yyyymmddhhmmss timestamp of a
+// pseudo-version.
+func (v *Version) pseudoVersionTimestamp() (string, bool) {
+	if len(v.parts) != 3 || v.pre == "" {
+		return "", false
+	}
+
+	ids := strings.Split(v.pre, ".")
+	last := ids[len(ids)-1]
+
+	// The commit is the last identifier, a timestamp and a revision hash
+	// separated by a hyphen.
+	i := strings.IndexByte(last, '-')
+	if i != len(pseudoVersionTimeLayout) || !containsOnly(last[:i], num) {
+		return "", false
+	}
+	if rev := last[i+1:]; rev == "" || !containsOnly(rev, allowedPseudoVersionRevision) {
+		return "", false
+	}
+
+	if len(ids) == 1 {
+		// Without a base version there is nothing before the commit, which
+		// is only allowed for vX.0.0.
+		if v.parts[1] != 0 || v.parts[2] != 0 {
+			return "", false
+		}
+	} else if ids[len(ids)-2] != "0" {
+		return "", false
+	}
+
+	return last[:i], true
+}
+
+const allowedPseudoVersionRevision = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Track returns the release track of the version for grouping builds, e.g. on
+// a dashboard: "major.minor" for releases, like 1.2 for 1.2.3, and
+// "major.minor-channel" for prereleases, like 1.2-beta for 1.2.0-beta.3.
+//
+// The channel is the first prerelease identifier, or "pre" if it is numeric
+// (1.2-pre for 1.2.0-1). Metadata is ignored, so all versions of a track give
+// the same string, suitable as a map key.
+func (v *Version) Track() string {
+	track := strconv.FormatUint(v.Part(1), 10) + "." + strconv.FormatUint(v.Minor(), 10)
+	if v.pre == "" {
+		return track
+	}
+
+	channel := v.PrereleaseParts()[0]
+	if containsOnly(channel, num) {
+		channel = "pre"
+	}
+
+	return track + "-" + channel
+}
+
 // Metadata returns the metadata on the version.
 func (v *Version) Metadata() string {
 	return v.metadata
@@ -261,13 +1062,10 @@ func (v *Version) isZero() bool {
 	return true
 }
 
-// originalVPrefix returns the original 'v' prefix if any.
+// originalVPrefix returns the original 'v' prefix (or any other prefix allowed
+// by NewVersionWithPrefixes) if any.
 func (v *Version) originalVPrefix() string {
-	// Note, only lowercase v is supported as a prefix by the parser.
-	if v.original != "" && v.original[:1] == "v" {
-		return v.original[:1]
-	}
-	return ""
+	return v.prefix
 }
 
 func (v *Version) ensurePartsNumber(expect int) {
@@ -278,6 +1076,21 @@ func (v *Version) ensurePartsNumber(expect int) {
 	v.parts = append(v.parts, make([]uint64, expect-len(v.parts))...)
 }
 
+// FillZeros produces a version padded with zero parts up to toParts parts, so
+// 1.2 with toParts=3 becomes 1.2.0. The prerelease, metadata and `v` prefix are
+// kept, and versions that already have at least toParts parts are unchanged.
+func (v *Version) FillZeros(toParts int) Version {
+	vNext := v.Copy()
+	if len(vNext.parts) >= toParts {
+		return vNext
+	}
+
+	vNext.ensurePartsNumber(toParts)
+	vNext.updateOriginal()
+
+	return vNext
+}
+
 // IncPart produces the next version on specific part
 // If the part is not exist
 // - increase the part number to specific
@@ -288,6 +1101,8 @@ func (v *Version) ensurePartsNumber(expect int) {
 // If the part is not last part
 // - will remove pre and metadata and increase the part
 // - following parts will be set to zero
+// The prefix (e.g. v) and the number of parts are kept, parts are only added
+// when part is beyond the last one, so v1.2.3.4 gives v1.2.3.5 for IncLast.
 func (v *Version) IncPart(part int) Version {
 	vNext := v.Copy()
 	vNext.ensurePartsNumber(part)
@@ -316,6 +1131,30 @@ func (v *Version) IncPart(part int) Version {
 	return vNext
 }
 
+// IncPartChecked is like IncPart but returns ErrPartOverflow instead of
+// wrapping the part around to zero when it is already the max uint64 value.
+func (v *Version) IncPartChecked(part int) (Version, error) {
+	if v.incIncreasesPart(part) && v.Part(part) == math.MaxUint64 {
+		return v.Copy(), ErrPartOverflow
+	}
+
+	return v.IncPart(part), nil
+}
+
+// incIncreasesPart reports whether IncPart(part) increases the part, it
+// doesn't when the part is the last one and only the prerelease is dropped.
+func (v *Version) incIncreasesPart(part int) bool {
+	return part < len(v.parts) || v.pre == ""
+}
+
+// WouldBreakOnInc reports whether IncPart(part) changes the major version,
+// which is a breaking change. This is only the case when increasing the first
+// part, unless the version is a single part prerelease (e.g. 1-beta) for which
+// IncPart(1) just drops the prerelease.
+func (v *Version) WouldBreakOnInc(part int) bool {
+	return part == 1 && v.incIncreasesPart(part)
+}
+
 // IncPatch produces the next patch (3rd part) version.
 // Same as IncPart(3)
 func (v *Version) IncPatch() Version {
@@ -340,6 +1179,121 @@ func (v *Version) IncLast() Version {
 	return v.IncPart(len(v.parts))
 }
 
+// NextMajor returns the (major+1).0.0 version, the exclusive upper bound of
+// the current major version.
+// Unlike IncMajor the result always has three parts and a prerelease is never
+// just dropped, so 1.2.3-beta gives 2.0.0 and 1.2.3.4 gives 2.0.0.
+//
+// NextMajor, NextMinor and NextPatch never give a lower version: a part already
+// at the max uint64 value carries into the previous one, so NextPatch of
+// 1.2.18446744073709551615 is 1.3.0. When all of them are at the max value
+// the result saturates, with the three parts at the max value.
+func (v *Version) NextMajor() Version {
+	return v.nextBoundary(1)
+}
+
+// NextMinor returns the major.(minor+1).0 version, the exclusive upper bound
+// of the current minor version.
+func (v *Version) NextMinor() Version {
+	return v.nextBoundary(2)
+}
+
+// NextPatch returns the major.minor.(patch+1) version, the exclusive upper
+// bound of the current patch version.
+// Unlike IncPatch 1.2.3-beta gives 1.2.4.
+func (v *Version) NextPatch() Version {
+	return v.nextBoundary(3)
+}
+
+func (v *Version) nextBoundary(part int) Version {
+	vNext, err := v.nextBoundaryChecked(part)
+	if err != nil {
+		vNext = *boundVersion([]uint64{math.MaxUint64, math.MaxUint64, math.MaxUint64}, "")
+		vNext.prefix = v.prefix
+		vNext.updateOriginal()
+	}
+
+	return vNext
+}
+
+// nextBoundaryChecked is like nextBoundary but returns ErrPartOverflow instead
+// of saturating when there is no next boundary.
+func (v *Version) nextBoundaryChecked(part int) (Version, error) {
+	vNext := nextBoundVersion(v, part)
+	if vNext == nil {
+		return v.Copy(), ErrPartOverflow
+	}
+
+	vNext.prefix = v.prefix
+	vNext.updateOriginal()
+
+	return *vNext, nil
+}
+
+// Bump returns the next version for one of the npm version keywords:
+//   - "major", "minor" and "patch" are the same as IncMajor, IncMinor and
+//     IncPatch
+//   - "premajor", "preminor" and "prepatch" return NextMajor, NextMinor and
+//     NextPatch with a `0` prerelease, e.g. 2.0.0-0 for 1.2.3 and premajor
+//   - "prerelease" increases the trailing numeric identifier of the prerelease
+//     (1.2.3-beta.1 gives 1.2.3-beta.2) or appends `.0` if there is none
+//     (1.2.3-beta gives 1.2.3-beta.0), releases are bumped like prepatch
+//
+// Metadata is always dropped. An error is returned for any other keyword, and
+// ErrPartOverflow when there is no next version.
+func (v *Version) Bump(kind string) (Version, error) {
+	var vNext Version
+	var err error
+
+	switch kind {
+	case "major":
+		return v.IncMajor(), nil
+	case "minor":
+		return v.IncMinor(), nil
+	case "patch":
+		return v.IncPatch(), nil
+	case "premajor":
+		vNext, err = v.nextBoundaryChecked(1)
+	case "preminor":
+		vNext, err = v.nextBoundaryChecked(2)
+	case "prepatch":
+		vNext, err = v.nextBoundaryChecked(3)
+	case "prerelease":
+		if v.pre == "" {
+			vNext, err = v.nextBoundaryChecked(3)
+			break
+		}
+
+		vNext = v.Copy()
+		vNext.metadata = ""
+		if n, ok := v.PrereleaseNumber(); ok {
+			if n == math.MaxUint64 {
+				return v.Copy(), ErrPartOverflow
+			}
+
+			parts := v.PrereleaseParts()
+			parts[len(parts)-1] = strconv.FormatUint(n+1, 10)
+			vNext.pre = strings.Join(parts, ".")
+		} else {
+			vNext.pre += ".0"
+		}
+		vNext.updateOriginal()
+
+		return vNext, nil
+	default:
+		return v.Copy(), fmt.Errorf("unknown bump kind %q", kind)
+	}
+
+	if err != nil {
+		return vNext, err
+	}
+
+	vNext.pre = "0"
+	vNext.updateOriginal()
+
+	return vNext, nil
+}
+
 // SetPrerelease defines the prerelease value.
 // Value must not include the required 'hyphen' prefix.
 func (v *Version) SetPrerelease(prerelease string) (Version, error) {
@@ -349,9 +1303,81 @@ func (v *Version) SetPrerelease(prerelease string) (Version, error) {
 			return vNext, err
 		}
 	}
-	vNext.pre = prerelease
-	vNext.updateOriginal()
-	return vNext, nil
+	vNext.pre = prerelease
+	vNext.updateOriginal()
+	return vNext, nil
+}
+
+// Core produces the version without its prerelease and metadata, e.g. to
+// finalize v1.2.3-rc.1+build into v1.2.3. All the number parts, including the
+// ones after the patch, and the `v` prefix are kept.
+func (v *Version) Core() Version {
+	vNext := v.Copy()
+	vNext.pre = ""
+	vNext.metadata = ""
+	vNext.updateOriginal()
+
+	return vNext
+}
+
+// TruncatePrerelease produces the version keeping only the first n prerelease
+// identifiers, e.g. 1.2.0-beta for 1.2.0-beta.3.build.7 and n=1. The
+// prerelease is kept whole if it has at most n identifiers and removed if n is
+// not positive. The metadata and `v` prefix are kept.
+//
+// As the identifiers come from a valid prerelease, the result is always valid.
+func (v *Version) TruncatePrerelease(n int) Version {
+	vNext := v.Copy()
+	if vNext.pre == "" {
+		return vNext
+	}
+
+	if n <= 0 {
+		vNext.pre = ""
+	} else if ids := strings.Split(vNext.pre, "."); len(ids) > n {
+		vNext.pre = strings.Join(ids[:n], ".")
+	}
+	vNext.updateOriginal()
+
+	return vNext
+}
+
+// PrereleaseSequence returns the n prereleases channel.1 to channel.n of the
+// version, e.g. 1.2.0-rc.1, 1.2.0-rc.2 and 1.2.0-rc.3 for 1.2.0, "rc" and 3.
+// The current prerelease and metadata of the version are replaced.
+//
+// nil is returned if n is not positive or channel is not a single valid
+// prerelease identifier.
+func (v *Version) PrereleaseSequence(channel string, n int) []Version {
+	if n <= 0 || ValidatePrereleaseParts([]string{channel}) != nil {
+		return nil
+	}
+
+	base := v.Copy()
+	base.metadata = ""
+
+	res := make([]Version, n)
+	for i := range res {
+		res[i], _ = base.SetPrerelease(channel + "." + strconv.Itoa(i+1))
+	}
+
+	return res
+}
+
+// PromoteIfPrereleaseAtLeast returns the release of the version, without
+// prerelease and metadata, if the trailing prerelease number is at least n,
+// e.g. 1.2.0 for 1.2.0-rc.3 and n = 3. Otherwise, including when there is no
+// prerelease number (see PrereleaseNumber), a copy of the version is returned.
+func (v *Version) PromoteIfPrereleaseAtLeast(n uint64) Version {
+	vNext := v.Copy()
+
+	if p, ok := v.PrereleaseNumber(); ok && p >= n {
+		vNext.pre = ""
+		vNext.metadata = ""
+		vNext.updateOriginal()
+	}
+
+	return vNext
 }
 
 // SetMetadata defines metadata value.
@@ -385,6 +1411,170 @@ func (v *Version) Equal(o *Version) bool {
 	return v.Compare(o) == 0
 }
 
+// EqualFold tests if two versions are equal like Equal, but compares the
+// prerelease identifiers case-insensitively, so 1.2.0-RC.1 equals 1.2.0-rc.1.
+// Metadata is ignored as usual.
+//
+// Note that SemVer compares prereleases case-sensitively, this is meant for
+// tolerant matching of versions from systems that disagree on the case.
+func (v *Version) EqualFold(o *Version) bool {
+	return CompareParts(v.parts, o.parts) == 0 && strings.EqualFold(v.pre, o.pre)
+}
+
+// IsUpgradeFrom tests if moving from o to this version is an upgrade, which
+// is the case when this version is greater than o.
+//
+// Prereleases are lower than their release, so 1.2.3 is an upgrade from
+// 1.2.3-rc.1 while 1.2.3-rc.1 is a downgrade from 1.2.3. Metadata is ignored:
+// 1.2.3+build.2 is neither an upgrade nor a downgrade from 1.2.3+build.1.
+func (v *Version) IsUpgradeFrom(o *Version) bool {
+	return v.GreaterThan(o)
+}
+
+// IsDowngradeFrom tests if moving from o to this version is a downgrade,
+// which is the case when this version is less than o. See IsUpgradeFrom for
+// the handling of prereleases and metadata.
+func (v *Version) IsDowngradeFrom(o *Version) bool {
+	return v.LessThan(o)
+}
+
+// IsPatchSuccessorOf tests if this version is a pure patch update from o,
+// e.g. to auto-approve it: it has the same major and minor versions, a greater
+// patch version and is not a prerelease. It is stricter than IsUpgradeFrom,
+// so 1.3.0 is not a patch successor of 1.2.3, and neither is 1.2.4-rc.1.
+//
+// Parts after the patch are ignored, so 1.2.3.1 is not a patch successor of
+// 1.2.3.
+func (v *Version) IsPatchSuccessorOf(o *Version) bool {
+	return v.pre == "" &&
+		v.Part(1) == o.Part(1) &&
+		v.Minor() == o.Minor() &&
+		v.Patch() > o.Patch()
+}
+
+// AtLeast parses both versions and tests if have is greater than or equal to
+// want. Versions are compared with Compare, so prereleases are lower than
+// their release (1.2.0-rc.1 is not at least 1.2.0) and metadata is ignored.
+func AtLeast(have string, want string) (bool, error) {
+	h, w, err := parsePair(have, want)
+	if err != nil {
+		return false, err
+	}
+
+	return h.Compare(w) >= 0, nil
+}
+
+// AtMost parses both versions and tests if have is less than or equal to
+// want. See AtLeast for the handling of prereleases.
+func AtMost(have string, want string) (bool, error) {
+	h, w, err := parsePair(have, want)
+	if err != nil {
+		return false, err
+	}
+
+	return h.Compare(w) <= 0, nil
+}
+
+// Between parses the versions and tests if have is within low and high, both
+// inclusive. See AtLeast for the handling of prereleases.
+func Between(have string, low string, high string) (bool, error) {
+	h, l, err := parsePair(have, low)
+	if err != nil {
+		return false, err
+	}
+
+	u, err := NewVersion(high)
+	if err != nil {
+		return false, err
+	}
+
+	return h.Compare(l) >= 0 && h.Compare(u) <= 0, nil
+}
+
+// RequireAtLeast returns an error like "current version 1.1.0 is below
+// required minimum 1.2.0" if current is lower than minimum, nil otherwise. It
+// is meant for start up checks, see AtLeast for the handling of prereleases.
+//
+// Invalid versions are reported with the offending input, the parse error can
+// still be matched with errors.Is.
+func RequireAtLeast(current, minimum string) error {
+	c, err := NewVersion(current)
+	if err != nil {
+		return wrapInvalidVersion(current, err)
+	}
+
+	m, err := NewVersion(minimum)
+	if err != nil {
+		return wrapInvalidVersion(minimum, err)
+	}
+
+	if c.Compare(m) < 0 {
+		return fmt.Errorf("current version %s is below required minimum %s", current, minimum)
+	}
+
+	return nil
+}
+
+func parsePair(a, b string) (*Version, *Version, error) {
+	va, err := NewVersion(a)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vb, err := NewVersion(b)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return va, vb, nil
+}
+
+// LooselyEqual tests if two versions are equal under looser rules than Equal,
+// useful to reconcile versions formatted by different systems:
+//   - numeric parts are compared with missing parts being zero (like Equal),
+//     so 1.2 equals 1.2.0.0
+//   - a release never equals a prerelease
+//   - prerelease identifiers are compared one by one with missing trailing
+//     identifiers being 0, so beta equals beta.0 but not beta.1
+//   - metadata is ignored
+func (v *Version) LooselyEqual(o *Version) bool {
+	if CompareParts(v.parts, o.parts) != 0 {
+		return false
+	}
+
+	if (v.pre == "") != (o.pre == "") {
+		return false
+	}
+
+	sparts := v.PrereleaseParts()
+	oparts := o.PrereleaseParts()
+	for len(sparts) < len(oparts) {
+		sparts = append(sparts, "0")
+	}
+	for len(oparts) < len(sparts) {
+		oparts = append(oparts, "0")
+	}
+
+	for i := range sparts {
+		if sparts[i] != oparts[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// EqualIgnoringFormat tests if both versions have the same numeric parts,
+// regardless of how they were written: the prefix, the number of parts and
+// leading zeros of the input don't matter, so v1.2 equals 1.2.0 (and 01.02.03
+// would equal 1.2.3).
+//
+// Only the numeric parts are compared, the prerelease and metadata are
+// ignored as well. Use Equal to take the prerelease into account.
+func (v *Version) EqualIgnoringFormat(o *Version) bool {
+	return CompareParts(v.parts, o.parts) == 0
+}
+
 func maxPartsNumberOf(v1, v2 *Version) int {
 	n1 := v1.PartsNumber()
 	n2 := v2.PartsNumber()
@@ -404,21 +1594,28 @@ func maxPartsNumberOf(v1, v2 *Version) int {
 // lower than the version without a prerelease. Compare always takes into account
 // prereleases. If you want to work with ranges using typical range syntaxes that
 // skip prereleases if the range is not looking for them use constraints.
+//
+// A nil version (either the receiver or o) is treated as the zero version, so
+// it equals 0.0.0 and is less than any other release.
 func (v *Version) Compare(o *Version) int {
-	n := maxPartsNumberOf(v, o)
+	if v == nil {
+		v = &Version{}
+	}
+	if o == nil {
+		o = &Version{}
+	}
 
-	// Compare parts from left to right
-	//  If a difference is found return the comparison.
-	for i := 1; i <= n; i++ {
-		if d := compareSegment(v.Part(i), o.Part(i)); d != 0 {
-			return d
-		}
+	if d := CompareParts(v.parts, o.parts); d != 0 {
+		return d
 	}
 
 	// At this point the version number parts are the same.
-	ps := v.pre
-	po := o.pre
+	return compareReleasePrerelease(v.pre, o.pre)
+}
 
+// compareReleasePrerelease compares the prereleases of two versions with the
+// same number parts, where no prerelease (a release) is the greatest.
+func compareReleasePrerelease(ps, po string) int {
 	if ps == "" && po == "" {
 		return 0
 	}
@@ -432,11 +1629,270 @@ func (v *Version) Compare(o *Version) int {
 	return comparePrerelease(ps, po)
 }
 
+// CompareWithChannelOrder compares this version to another one like Compare,
+// but orders prereleases by their channel (the first prerelease identifier)
+// as ranked in order. For example with []string{"snapshot", "alpha", "beta",
+// "rc"} 1.0.0-snapshot.5 < 1.0.0-alpha.1, while Compare puts snapshot after
+// alpha. A release is still greater than any of its prereleases.
+//
+// Channels that are not in order rank after all the listed ones, so with the
+// order above 1.0.0-rc.1 < 1.0.0-dev.1. When both channels have the same rank,
+// that is the same listed channel or two unlisted ones, the prereleases are
+// compared like Compare does.
+func (v *Version) CompareWithChannelOrder(o *Version, order []string) int {
+	if v == nil || o == nil || v.pre == "" || o.pre == "" || CompareParts(v.parts, o.parts) != 0 {
+		return v.Compare(o)
+	}
+
+	if d := compareSegment(uint64(channelRank(v.pre, order)), uint64(channelRank(o.pre, order))); d != 0 {
+		return d
+	}
+
+	return comparePrerelease(v.pre, o.pre)
+}
+
+// channelRank returns the index in order of the first identifier of pre, or
+// len(order) if it is not listed.
+func channelRank(pre string, order []string) int {
+	channel := pre
+	if i := strings.IndexByte(pre, '.'); i != -1 {
+		channel = pre[:i]
+	}
+
+	for i, c := range order {
+		if c == channel {
+			return i
+		}
+	}
+
+	return len(order)
+}
+
+// CompareParts compares two version numbers given as their parts. It returns
+// -1, 0, or 1 if a is smaller, equal, or larger than b. Missing parts are
+// treated as zero, so []uint64{1, 2} equals []uint64{1, 2, 0}.
+//
+// This is the numeric part of Compare, without any prerelease handling, and
+// avoids creating Version instances.
+func CompareParts(a, b []uint64) int {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+
+	// Compare parts from left to right
+	//  If a difference is found return the comparison.
+	for i := 0; i < n; i++ {
+		var x, y uint64
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+
+		if d := compareSegment(x, y); d != 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// CompareTotal compares this version to another one like Compare, but only
+// returns 0 if both versions have the same string form, which makes it suitable
+// as a deterministic sort key.
+//
+// Note that this is not SemVer precedence: when Compare finds the versions
+// equal they are further ordered by their metadata (compared as plain strings,
+// no metadata first) and then by their number of parts (fewer parts first),
+// so 1.0.0 < 1.0.0+a < 1.0.0+b and 1.0 < 1.0.0.
+func (v *Version) CompareTotal(o *Version) int {
+	if d := v.Compare(o); d != 0 {
+		return d
+	}
+
+	if d := strings.Compare(v.metadata, o.metadata); d != 0 {
+		return d
+	}
+
+	return compareSegment(uint64(len(v.parts)), uint64(len(o.parts)))
+}
+
+// CompareCore compares only the numeric parts of this version to another one,
+// returning -1, 0, or 1 like Compare. Missing parts are treated as zero.
+//
+// Unlike Compare, which puts a prerelease before its release, the prerelease
+// and metadata are completely ignored, so 1.2.3-alpha and 1.2.3 are equal.
+func (v *Version) CompareCore(o *Version) int {
+	if v == nil {
+		v = &Version{}
+	}
+	if o == nil {
+		o = &Version{}
+	}
+
+	return CompareParts(v.parts, o.parts)
+}
+
+// CompareWithMask compares this version to another one like Compare, but
+// only takes into account the numeric parts whose entry in includeParts is
+// true, e.g. []bool{true, true, true, false} ignores a build counter in the
+// 4th part while keeping it in the string.
+//
+// Parts beyond the end of includeParts are compared as usual, so a nil mask
+// makes it the same as Compare. When all the included parts are equal the
+// prereleases are compared like Compare does.
+func (v *Version) CompareWithMask(o *Version, includeParts []bool) int {
+	if v == nil {
+		v = &Version{}
+	}
+	if o == nil {
+		o = &Version{}
+	}
+
+	n := maxPartsNumberOf(v, o)
+	for i := 0; i < n; i++ {
+		if i < len(includeParts) && !includeParts[i] {
+			continue
+		}
+
+		if d := compareSegment(v.Part(i+1), o.Part(i+1)); d != 0 {
+			return d
+		}
+	}
+
+	return compareReleasePrerelease(v.pre, o.pre)
+}
+
+// firstDifferentPart returns the (1-based) number of the first numeric part
+// that differs between the two versions, or 0 if all parts are equal.
+func firstDifferentPart(v, o *Version) int {
+	n := maxPartsNumberOf(v, o)
+	for i := 1; i <= n; i++ {
+		if v.Part(i) != o.Part(i) {
+			return i
+		}
+	}
+
+	return 0
+}
+
+// Delta returns the signed differences between the major, minor and patch
+// versions of v and o (v minus o), missing parts are treated as zero. For
+// example 1.5.0 and 1.2.7 give (0, 3, -7), a downgrade has negative values.
+//
+// Parts larger than the max int64 value are not supported.
+func (v *Version) Delta(o *Version) (majorDiff, minorDiff, patchDiff int64) {
+	return int64(v.Part(1)) - int64(o.Part(1)),
+		int64(v.Part(2)) - int64(o.Part(2)),
+		int64(v.Part(3)) - int64(o.Part(3))
+}
+
+// RequiredBump returns the smallest kind of bump of current needed to reach at
+// least minimum: "major", "minor" or "patch", depending on the first part that
+// differs, or "" if current already is at least minimum. For 1.2.3 and 1.5.0
+// it is "minor".
+//
+// A difference at the 4th part or later, or only in the prerelease (e.g.
+// 1.2.3-rc.1 and 1.2.3), requires a "patch" bump.
+func RequiredBump(current, minimum *Version) string {
+	if current.Compare(minimum) >= 0 {
+		return ""
+	}
+
+	switch firstDifferentPart(current, minimum) {
+	case 1:
+		return "major"
+	case 2:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// compareDistance compares the distance from lo to mid with the distance from
+// mid to hi, where lo <= mid <= hi. The numeric parts are subtracted with
+// borrowing, so 2.0.0 is nearer to 1.9.0 than to 1.0.0, and missing parts are
+// zero. It returns -1 if mid is nearer to lo, 1 if it is nearer to hi and 0
+// if both distances are equal.
+func compareDistance(lo, mid, hi *Version) int {
+	n := maxPartsNumberOf(lo, hi)
+	if mid.PartsNumber() > n {
+		n = mid.PartsNumber()
+	}
+
+	for i := 1; i <= n; i++ {
+		// Each part of a distance may be negative after the parts before it
+		// borrowed from it, only the first non zero one is always positive.
+		a, aNeg := partDelta(mid.Part(i), lo.Part(i))
+		b, bNeg := partDelta(hi.Part(i), mid.Part(i))
+
+		switch {
+		case aNeg != bNeg:
+			if aNeg {
+				return -1
+			}
+			return 1
+		case a == b:
+			continue
+		case (a < b) != aNeg:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	return 0
+}
+
+// partDelta returns the absolute difference of a and b, and whether a is
+// lower than b.
+func partDelta(a, b uint64) (uint64, bool) {
+	if a < b {
+		return b - a, true
+	}
+	return a - b, false
+}
+
+// ReleaseLabel describes the transition from one version to another for use
+// in release notes. It returns one of
+//   - "Downgrade" if to is lower than from
+//   - "Prerelease" if to is a prerelease
+//   - "Major release", "Minor release" or "Patch release" depending on the
+//     first part that changed (a change at the 4th part or later, or a
+//     prerelease promoted to its release, is a "Patch release")
+//
+// An empty string is returned if both versions are equal.
+func ReleaseLabel(from, to *Version) string {
+	switch to.Compare(from) {
+	case -1:
+		return "Downgrade"
+	case 0:
+		return ""
+	}
+
+	if to.pre != "" {
+		return "Prerelease"
+	}
+
+	switch firstDifferentPart(from, to) {
+	case 1:
+		return "Major release"
+	case 2:
+		return "Minor release"
+	default:
+		return "Patch release"
+	}
+}
+
 func (v *Version) updateBy(o *Version) {
 	v.parts = o.parts
 	v.pre = o.pre
 	v.metadata = o.metadata
 	v.original = o.original
+	v.prefix = o.prefix
+	v.input = o.input
 }
 
 // UnmarshalJSON implements JSON.Unmarshaler interface.
@@ -447,7 +1903,7 @@ func (v *Version) UnmarshalJSON(b []byte) error {
 	}
 	temp, err := NewVersion(s)
 	if err != nil {
-		return err
+		return wrapInvalidVersion(s, err)
 	}
 	v.updateBy(temp)
 
@@ -458,7 +1914,7 @@ func (v *Version) UnmarshalJSON(b []byte) error {
 func (v *Version) UnmarshalText(text []byte) error {
 	temp, err := NewVersion(string(text))
 	if err != nil {
-		return err
+		return wrapInvalidVersion(string(text), err)
 	}
 
 	*v = *temp
@@ -466,11 +1922,29 @@ func (v *Version) UnmarshalText(text []byte) error {
 	return nil
 }
 
+// wrapInvalidVersion adds the offending input to a parse error. The original
+// error can still be matched with errors.Is.
+func wrapInvalidVersion(s string, err error) error {
+	return fmt.Errorf("invalid version %q: %w", s, err)
+}
+
 // MarshalText implements the encoding.TextMarshaler interface.
 func (v Version) MarshalText() ([]byte, error) {
 	return []byte(v.String()), nil
 }
 
+// AppendText implements the encoding.TextAppender interface (Go 1.24), it
+// appends the same text as MarshalText to b.
+func (v Version) AppendText(b []byte) ([]byte, error) {
+	return append(b, v.String()...), nil
+}
+
+// AppendBinary implements the encoding.BinaryAppender interface (Go 1.24).
+// The binary form is the same as the text form.
+func (v Version) AppendBinary(b []byte) ([]byte, error) {
+	return v.AppendText(b)
+}
+
 // MarshalJSON implements json.Marshaler interface.
 func (v Version) MarshalJSON() ([]byte, error) {
 	return json.Marshal(v.String())
@@ -624,6 +2098,129 @@ func validatePrerelease(p string) error {
 	return nil
 }
 
+// ValidatePrereleaseDetailed validates a prerelease like SetPrerelease does,
+// but names the first invalid character in the returned error, for example
+// `invalid character '☃' in prerelease`. Numeric identifiers with a leading
+// zero still return ErrSegmentStartsZero.
+func ValidatePrereleaseDetailed(p string) error {
+	for _, part := range strings.Split(p, ".") {
+		if containsOnly(part, num) {
+			if len(part) > 1 && part[0] == '0' {
+				return ErrSegmentStartsZero
+			}
+			continue
+		}
+
+		for _, r := range part {
+			if !strings.ContainsRune(allowed, r) {
+				return fmt.Errorf("invalid character %q in prerelease", r)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidatePrereleaseParts validates prerelease identifiers that are not yet
+// joined with dots, e.g. []string{"beta", "1"} for the prerelease beta.1.
+//
+// The returned error names the index of the first invalid identifier and wraps
+// ErrSegmentStartsZero for numeric identifiers with a leading zero or
+// ErrInvalidPrerelease otherwise. Unlike SetPrerelease, empty identifiers are
+// rejected as required by the spec.
+func ValidatePrereleaseParts(parts []string) error {
+	for i, p := range parts {
+		var err error
+		switch {
+		case p == "":
+			err = ErrInvalidPrerelease
+		case containsOnly(p, num):
+			if len(p) > 1 && p[0] == '0' {
+				err = ErrSegmentStartsZero
+			}
+		case !containsOnly(p, allowed):
+			err = ErrInvalidPrerelease
+		}
+
+		if err != nil {
+			return fmt.Errorf("prerelease identifier %d (%q): %w", i, p, err)
+		}
+	}
+
+	return nil
+}
+
+// CheckSemVer2 validates s against the full SemVer 2.0.0 grammar, which is
+// stricter than StrictNewVersion: exactly three version parts are required.
+// The returned error wraps ErrInvalidSemVer and describes the violated rule,
+// e.g. "invalid Semantic Version: expected three version parts, got 2".
+func CheckSemVer2(s string) error {
+	if s == "" {
+		return ErrEmptyString
+	}
+
+	core, pre, metadata := s, "", ""
+	hasPre, hasMetadata := false, false
+	if i := strings.Index(core, "+"); i != -1 {
+		core, metadata, hasMetadata = core[:i], core[i+1:], true
+	}
+	if i := strings.Index(core, "-"); i != -1 {
+		core, pre, hasPre = core[:i], core[i+1:], true
+	}
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semVer2Error("expected three version parts, got %d", len(parts))
+	}
+
+	for i, p := range parts {
+		if p == "" || !containsOnly(p, num) {
+			return semVer2Error("version part %d (%q) is not a number", i+1, p)
+		}
+		if len(p) > 1 && p[0] == '0' {
+			return semVer2Error("version part %d (%q) has a leading zero", i+1, p)
+		}
+		if _, err := strconv.ParseUint(p, 10, 64); err != nil {
+			return semVer2Error("version part %d (%q) is too large", i+1, p)
+		}
+	}
+
+	if hasPre {
+		for _, p := range strings.Split(pre, ".") {
+			if err := checkSemVer2Identifier("prerelease", p); err != nil {
+				return err
+			}
+			if len(p) > 1 && p[0] == '0' && containsOnly(p, num) {
+				return semVer2Error("prerelease identifier %q has a leading zero", p)
+			}
+		}
+	}
+
+	if hasMetadata {
+		for _, p := range strings.Split(metadata, ".") {
+			if err := checkSemVer2Identifier("metadata", p); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func checkSemVer2Identifier(kind, p string) error {
+	if p == "" {
+		return semVer2Error("%s identifier is empty", kind)
+	}
+	if !containsOnly(p, allowed) {
+		return semVer2Error("%s identifier %q contains invalid characters", kind, p)
+	}
+	return nil
+}
+
+func semVer2Error(format string, a ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidSemVer, fmt.Sprintf(format, a...))
+}
+
 // From the spec, "Build metadata MAY be denoted by
 // appending a plus sign and a series of dot separated identifiers immediately
 // following the patch or pre-release version. Identifiers MUST comprise only
@@ -3,8 +3,13 @@ package semver
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"testing"
+	"text/template"
+	"time"
 
 	"github.com/ImSingee/tt"
 )
@@ -85,6 +90,320 @@ func TestNewVersion(t *testing.T) {
 	}
 }
 
+func TestNewVersionCached(t *testing.T) {
+	defer ClearVersionCache()
+
+	v1, err := NewVersionCached("v1.2.3-beta")
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "v1.2.3-beta", v1.Original())
+
+	v2, err := NewVersionCached("v1.2.3-beta")
+	tt.AssertIsNotError(t, err)
+	tt.AssertTrue(t, v1 == v2)
+
+	_, err = NewVersionCached("foo")
+	tt.AssertEqual(t, ErrInvalidCharacters, err)
+	_, cached := versionCache.Load("foo")
+	tt.AssertFalse(t, cached)
+
+	ClearVersionCache()
+	v3, err := NewVersionCached("v1.2.3-beta")
+	tt.AssertIsNotError(t, err)
+	tt.AssertTrue(t, v1 != v3)
+	tt.AssertTrue(t, v1.Equal(v3))
+}
+
+func TestNewVersionTrimDots(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2.3.", "1.2.3"},
+		{".1.2", "1.2"},
+		{"..1.2..", "1.2"},
+		{"v1.2.3.", "v1.2.3"},
+		{"1.2.3", "1.2.3"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v, err := NewVersionTrimDots(tc.version)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.expected, v.Original())
+		})
+	}
+
+	for _, v := range []string{".", "..", "1..2", "1.2.beta."} {
+		t.Run(v, func(t *testing.T) {
+			_, err := NewVersionTrimDots(v)
+			tt.AssertIsError(t, err)
+		})
+	}
+
+	// strict parsing is not affected
+	_, err := StrictNewVersion("1.")
+	tt.AssertIsError(t, err)
+}
+
+func TestNewVersionWithFixup(t *testing.T) {
+	calls := 0
+	stripFinal := func(s string) string {
+		calls++
+		return strings.TrimSuffix(s, ".FINAL")
+	}
+
+	v, err := NewVersionWithFixup("1.2.3", stripFinal)
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "1.2.3", v.Original())
+	tt.AssertEqual(t, 0, calls)
+
+	v, err = NewVersionWithFixup("v1.2.3.FINAL", stripFinal)
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "v1.2.3", v.Original())
+	tt.AssertEqual(t, 1, calls)
+
+	// the error of the first attempt is returned
+	_, err = NewVersionWithFixup("1.2.3_beta", func(string) string { return "" })
+	tt.AssertEqual(t, ErrInvalidCharacters, err)
+
+	_, err = NewVersionWithFixup("", nil)
+	tt.AssertEqual(t, ErrEmptyString, err)
+}
+
+func TestNewVersionCommaSeparated(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1,2,3", "1.2.3"},
+		{"v1,2", "1.2"},
+		{"1,2,3-beta.1+build.5", "1.2.3-beta.1+build.5"},
+		{"1.2,3", "1.2.3"},
+		{"1.2.3", "1.2.3"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v, err := NewVersionCommaSeparated(tc.version)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.version, v.Original())
+			tt.AssertEqual(t, 0, v.Compare(MustParse(tc.expected)))
+		})
+	}
+
+	v, err := NewVersionCommaSeparated("1,2,3")
+	tt.AssertIsNotError(t, err)
+	next := v.IncPatch()
+	tt.AssertEqual(t, "1.2.4", next.String())
+	tt.AssertEqual(t, "1.2.4", next.Original())
+
+	// The prefix is accepted like with NewVersion.
+	v, err = NewVersionCommaSeparated("v1,2")
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "v1,2", v.Original())
+	tt.AssertEqual(t, "1.2", v.String())
+
+	// Everything but Original() uses dots and can be parsed again.
+	v, err = NewVersionCommaSeparated("v1,2,3-beta.1")
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "1.2.3-beta.1", v.String())
+	c := v.Copy()
+	tt.AssertEqual(t, "v1,2,3-beta.1", c.Original())
+
+	reparsed, err := NewVersion(v.String())
+	tt.AssertIsNotError(t, err)
+	tt.AssertTrue(t, reparsed.Equal(v))
+
+	data, err := json.Marshal(v)
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, `"1.2.3-beta.1"`, string(data))
+
+	var unmarshaled Version
+	tt.AssertIsNotError(t, json.Unmarshal(data, &unmarshaled))
+	tt.AssertTrue(t, unmarshaled.Equal(v))
+
+	text, err := v.MarshalText()
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "1.2.3-beta.1", string(text))
+	text, err = v.AppendText(nil)
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "1.2.3-beta.1", string(text))
+
+	value, err := v.Value()
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "1.2.3-beta.1", value)
+
+	v, err = NewVersionCommaSeparated("1,2,3,4")
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "=1.2.3.4", v.AsRangeConstraint().String())
+
+	v, err = NewVersionCommaSeparated("1,2,3")
+	tt.AssertIsNotError(t, err)
+	cs := ExcludeVersions(mustNewConstraint("^1"), []*Version{v})
+	tt.AssertFalse(t, cs.Check(MustParse("1.2.3")))
+	tt.AssertTrue(t, cs.Check(MustParse("1.2.4")))
+
+	for _, v := range []string{"", "1,,2", "1,2,3-beta,1", "1,2,3+build,5", "1;2;3"} {
+		t.Run(v, func(t *testing.T) {
+			_, err := NewVersionCommaSeparated(v)
+			tt.AssertIsError(t, err)
+		})
+	}
+}
+
+func TestSplitVersion(t *testing.T) {
+	tests := []struct {
+		version    string
+		core       string
+		prerelease string
+		metadata   string
+		prefix     string
+	}{
+		{"1.2.3", "1.2.3", "", "", ""},
+		{"v1.2.3", "1.2.3", "", "", "v"},
+		{"v1.2.3-beta.1+build.5", "1.2.3", "beta.1", "build.5", "v"},
+		{"1.2.3+build-5", "1.2.3+build", "5", "", ""},
+		{"1.2.3-rc-1+build-5", "1.2.3", "rc-1", "build-5", ""},
+		{"release-1.2", "1.2", "", "", "release-"},
+		{"1..2-", "1..2", "", "", ""},
+		{"01.2.3-01+", "01.2.3", "01", "", ""},
+		{"foo", "", "", "", "foo"},
+		{"", "", "", "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			core, pre, meta, prefix := SplitVersion(tc.version)
+			tt.AssertEqual(t, tc.core, core)
+			tt.AssertEqual(t, tc.prerelease, pre)
+			tt.AssertEqual(t, tc.metadata, meta)
+			tt.AssertEqual(t, tc.prefix, prefix)
+		})
+	}
+}
+
+func TestParseNoPrefix(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected error
+	}{
+		{"1.2.3", nil},
+		{"1.2.3-beta+meta", nil},
+		{"v1.2.3", ErrInvalidCharacters},
+		{"V1.2.3", ErrInvalidCharacters},
+		{"v1", ErrInvalidCharacters},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			_, err := ParseNoPrefix(tc.version)
+			tt.AssertEqual(t, tc.expected, err)
+
+			_, err = StrictNewVersion(tc.version)
+			tt.AssertEqual(t, tc.expected, err)
+		})
+	}
+}
+
+func TestNewVersionWithPrefixes(t *testing.T) {
+	prefixes := []string{"v", "version", "version-", "release-"}
+
+	tests := []struct {
+		version  string
+		expected string
+		prefix   string
+	}{
+		{"1.2.3", "1.2.3", ""},
+		{"v1.2.3", "1.2.3", "v"},
+		{"version1.2.3", "1.2.3", "version"},
+		{"version-1.2.3-beta", "1.2.3-beta", "version-"},
+		{"release-1.2", "1.2", "release-"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v, err := NewVersionWithPrefixes(tc.version, prefixes...)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.expected, v.String())
+			tt.AssertEqual(t, tc.version, v.Original())
+			tt.AssertEqual(t, tc.prefix, v.originalVPrefix())
+
+			next := v.IncMinor()
+			tt.AssertEqual(t, tc.prefix, next.originalVPrefix())
+			tt.AssertEqual(t, tc.prefix+next.String(), next.Original())
+		})
+	}
+
+	for _, s := range []string{"V1.2.3", "release1.2.3", "rel-1.2.3", "version--1.2.3"} {
+		t.Run(s, func(t *testing.T) {
+			_, err := NewVersionWithPrefixes(s, prefixes...)
+			tt.AssertIsError(t, err)
+		})
+	}
+
+	_, err := NewVersionWithPrefixes("v1.2.3")
+	tt.AssertIsError(t, err)
+}
+
+func TestNewVersionMaxParts(t *testing.T) {
+	tests := []struct {
+		version  string
+		max      int
+		expected error
+	}{
+		{"1.2.3", 3, nil},
+		{"v1.2.3-beta+meta", 3, nil},
+		{"1.2", 3, nil},
+		{"1.2.3.4", 3, ErrTooManyParts},
+		{"1.2.3.4.5.6", 3, ErrTooManyParts},
+		{"1.2.3.4", 4, nil},
+		{"1.2.beta", 3, ErrInvalidCharacters},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v, err := NewVersionMaxParts(tc.version, tc.max)
+			tt.AssertEqual(t, tc.expected, err)
+			if tc.expected == nil {
+				tt.AssertEqual(t, tc.version, v.Original())
+			}
+		})
+	}
+}
+
+func TestParseEnvVersion(t *testing.T) {
+	tests := []struct {
+		kv       string
+		expected string
+		err      string
+	}{
+		{"VERSION=1.2.3", "1.2.3", ""},
+		{"VERSION=v1.2.3-beta", "v1.2.3-beta", ""},
+		{" VERSION = 1.2.3 ", "1.2.3", ""},
+		{`VERSION="1.2.3"`, "1.2.3", ""},
+		{"VERSION='1.2.3'", "1.2.3", ""},
+		{`VERSION="1.2.3'`, "", `invalid version "\"1.2.3'": invalid characters in version`},
+		{"VERSION=", "", `invalid version "": version string empty`},
+		{"VERSION", "", `expected VERSION=value, got "VERSION"`},
+		{"APP_VERSION=1.2.3", "", `unexpected key "APP_VERSION", expected "VERSION"`},
+		{"VERSION=foo", "", `invalid version "foo": invalid characters in version`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.kv, func(t *testing.T) {
+			v, err := ParseEnvVersion(tc.kv, "VERSION")
+			if tc.err != "" {
+				tt.AssertIsError(t, err)
+				tt.AssertEqual(t, tc.err, err.Error())
+				return
+			}
+
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.expected, v.Original())
+		})
+	}
+}
+
 func TestNewVersionByParts(t *testing.T) {
 	t.Run("nil", func(t *testing.T) {
 		v := NewVersionByParts()
@@ -101,6 +420,38 @@ func TestNewVersionByParts(t *testing.T) {
 	})
 }
 
+func TestRebuild(t *testing.T) {
+	v := NewVersionByParts(1, 2, 3)
+	v.metadata = "build.5"
+	tt.AssertEqual(t, "1.2.3", v.Original())
+
+	rebuilt := v.Rebuild()
+	tt.AssertEqual(t, "1.2.3+build.5", rebuilt.Original())
+	tt.AssertEqual(t, "1.2.3+build.5", rebuilt.String())
+	tt.AssertEqual(t, "1.2.3", v.Original())
+
+	v = MustParse("v1.2.3")
+	v.parts[2] = 4
+	v.pre = "rc.1"
+	rebuilt = v.Rebuild()
+	tt.AssertEqual(t, "v1.2.4-rc.1", rebuilt.Original())
+	tt.AssertEqual(t, "1.2.4-rc.1", rebuilt.String())
+}
+
+func TestNewCalVer(t *testing.T) {
+	date := time.Date(2023, time.April, 7, 12, 0, 0, 0, time.UTC)
+
+	tt.AssertEqual(t, "2023.4.0", NewCalVer(date, 0).Original())
+	tt.AssertEqual(t, "2023.4.3", NewCalVer(date, 3).Original())
+	tt.AssertEqual(t, "2023.4.3", NewCalVerWithLayout(CalVerYearMonthMicro, date, 3).Original())
+	tt.AssertEqual(t, "2023.4.7", NewCalVerWithLayout(CalVerYearMonthDay, date, 0).Original())
+	tt.AssertEqual(t, "2023.4.7.2", NewCalVerWithLayout(CalVerYearMonthDay, date, 2).Original())
+
+	// calendar versions must sort by date
+	next := time.Date(2023, time.December, 1, 0, 0, 0, 0, time.UTC)
+	tt.AssertTrue(t, NewCalVer(date, 9).LessThan(NewCalVer(next, 0)))
+}
+
 func TestOriginal(t *testing.T) {
 	tests := []string{
 		"1.2.3",
@@ -134,6 +485,176 @@ func TestOriginal(t *testing.T) {
 	}
 }
 
+func TestPaddedString(t *testing.T) {
+	tests := []struct {
+		version  string
+		width    int
+		expected string
+	}{
+		{"1.2.3", 5, "00001.00002.00003~"},
+		{"v1.2.3+meta", 5, "00001.00002.00003~"},
+		{"1.2.3-beta.2", 3, "001.002.003-beta,002"},
+		{"1.2.3-alpha-x.b", 3, "001.002.003-alpha-x,b"},
+		{"1.2.3-rc1", 3, "001.002.003-rc1"},
+		{"1.2.3.4", 2, "01.02.03.04~"},
+		{"123456.2.3", 3, "123456.002.003~"},
+	}
+
+	for _, tc := range tests {
+		if a := MustParse(tc.version).PaddedString(tc.width); a != tc.expected {
+			t.Errorf("PaddedString(%d) of %q expected %q but got %q", tc.width, tc.version, tc.expected, a)
+		}
+	}
+
+	// string order must match version order
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-1.a",
+		"1.0.0-a",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.2",
+		"1.0.0-alpha.10",
+		"1.0.0-alpha-x",
+		"1.0.0-beta",
+		"1.0.0",
+		"1.0.1",
+		"1.10.0",
+		"10.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		a := MustParse(ordered[i-1]).PaddedString(4)
+		b := MustParse(ordered[i]).PaddedString(4)
+		if a >= b {
+			t.Errorf("Expected %q (%q) to sort before %q (%q)", ordered[i-1], a, ordered[i], b)
+		}
+	}
+}
+
+func TestStorageKey(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2.3", "00000000000000000001.00000000000000000002.00000000000000000003."},
+		{"v1.2.3-beta.2+build.5", "00000000000000000001.00000000000000000002.00000000000000000003-beta.00000000000000000002+build.5"},
+		{"1.2", "00000000000000000001.00000000000000000002."},
+		{"1.2.3-rc1+001", "00000000000000000001.00000000000000000002.00000000000000000003-rc1+001"},
+		{"18446744073709551615.0.0-0", "18446744073709551615.00000000000000000000.00000000000000000000-00000000000000000000"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v := MustParse(tc.version)
+			key := v.StorageKey()
+			tt.AssertEqual(t, tc.expected, key)
+
+			decoded, err := ParseStorageKey(key)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, v.String(), decoded.String())
+		})
+	}
+
+	// string order must match version order
+	ordered := []string{
+		"0.9.9",
+		"1.0.0-alpha",
+		"1.0.0-alpha+build",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.2",
+		"1.0.0-alpha.10",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0",
+		"1.0.0.1-rc.1",
+		"1.0.0.1",
+		"1.0.1",
+		"1.10.0",
+		"10.0.0",
+	}
+	for i := 1; i < len(ordered); i++ {
+		a := MustParse(ordered[i-1]).StorageKey()
+		b := MustParse(ordered[i]).StorageKey()
+		if a >= b {
+			t.Errorf("Expected %q (%q) to sort before %q (%q)", ordered[i-1], a, ordered[i], b)
+		}
+	}
+
+	for _, s := range []string{
+		"",
+		"1.2.3",
+		"00000000000000000001.00000000000000000002",
+		"00000000000000000001.2.00000000000000000003.",
+		"00000000000000000001.00000000000000000002.00000000000000000003-be_ta",
+	} {
+		_, err := ParseStorageKey(s)
+		tt.AssertIsError(t, err)
+	}
+}
+
+func TestDockerTag(t *testing.T) {
+	tests := []struct {
+		version  string
+		tag      string
+		validTag bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"v1.2.3-rc.1", "1.2.3-rc.1", true},
+		{"1.2.3-rc.1+build.5", "1.2.3-rc.1_build.5", false},
+		{"1.2.3-rc-1+build.5.x", "1.2.3-rc-1_build.5.x", false},
+		{"1.2.3-" + strings.Repeat("a", 130), "1.2.3-" + strings.Repeat("a", 130), false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v := MustParse(tc.version)
+			tt.AssertEqual(t, tc.tag, v.DockerTag())
+			tt.AssertEqual(t, tc.validTag, v.IsValidDockerTag())
+
+			parsed, err := ParseDockerTag(v.DockerTag())
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, v.String(), parsed.String())
+		})
+	}
+
+	v, err := ParseDockerTag("v1.2.3_build.5")
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, "build.5", v.Metadata())
+
+	_, err = ParseDockerTag("1.2.3_a_b")
+	tt.AssertEqual(t, ErrInvalidMetadata, err)
+}
+
+func TestToEcosystem(t *testing.T) {
+	tests := []struct {
+		version string
+		npm     string
+		golang  string
+		python  string
+		docker  string
+	}{
+		{"1.2.3", "1.2.3", "v1.2.3", "1.2.3", "1.2.3"},
+		{"v1.2.3-rc.1", "1.2.3-rc.1", "v1.2.3-rc.1", "1.2.3rc1", "1.2.3-rc.1"},
+		{"1.2.3-alpha.2+build.5", "1.2.3-alpha.2+build.5", "v1.2.3-alpha.2+build.5", "1.2.3a2+build.5", "1.2.3-alpha.2_build.5"},
+		{"1.2.3-beta", "1.2.3-beta", "v1.2.3-beta", "1.2.3b", "1.2.3-beta"},
+		{"1.2.3-dev.4", "1.2.3-dev.4", "v1.2.3-dev.4", "1.2.3.dev4", "1.2.3-dev.4"},
+		{"1.2.3-RC.1", "1.2.3-RC.1", "v1.2.3-RC.1", "1.2.3rc1", "1.2.3-RC.1"},
+		{"1.2.3-snapshot.1", "1.2.3-snapshot.1", "v1.2.3-snapshot.1", "1.2.3-snapshot.1", "1.2.3-snapshot.1"},
+		{"1.2.3-rc.1.2", "1.2.3-rc.1.2", "v1.2.3-rc.1.2", "1.2.3-rc.1.2", "1.2.3-rc.1.2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v := MustParse(tc.version)
+			tt.AssertEqual(t, tc.npm, v.ToEcosystem("npm"))
+			tt.AssertEqual(t, tc.golang, v.ToEcosystem("go"))
+			tt.AssertEqual(t, tc.python, v.ToEcosystem("python"))
+			tt.AssertEqual(t, tc.docker, v.ToEcosystem("docker"))
+			tt.AssertEqual(t, v.String(), v.ToEcosystem("cargo"))
+		})
+	}
+}
+
 func TestParts(t *testing.T) {
 	v, err := NewVersion("1.2.3-beta.1+build.123")
 	if err != nil {
@@ -161,6 +682,304 @@ func TestParts(t *testing.T) {
 	if v.Metadata() != "build.123" {
 		t.Error("Metadata() returning wrong value")
 	}
+	if !v.IsPrerelease() || MustParse("1.2.3+build.123").IsPrerelease() {
+		t.Error("IsPrerelease() returning wrong value")
+	}
+}
+
+func TestIsDevelopment(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"0.1.0", true},
+		{"0.0.1-rc.1", true},
+		{"v0", true},
+		{"1.0.0-alpha", false},
+		{"1.0.0", false},
+		{"10.0.0", false},
+	}
+
+	for _, tc := range tests {
+		tt.AssertEqual(t, tc.expected, MustParse(tc.version).IsDevelopment())
+	}
+
+	tt.AssertTrue(t, NewVersionByParts().IsDevelopment())
+}
+
+func TestPseudoVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		pseudo   bool
+		expected string
+	}{
+		{"v0.0.0-20230101000000-abcdef123456", true, "2023-01-01T00:00:00Z"},
+		{"v2.0.0-20230615123045-abcdef123456+incompatible", true, "2023-06-15T12:30:45Z"},
+		{"v1.2.4-0.20230101000000-abcdef123456", true, "2023-01-01T00:00:00Z"},
+		{"v1.2.3-pre.0.20230101000000-abcdef123456", true, "2023-01-01T00:00:00Z"},
+		{"1.2.4-0.20230101000000-abcdef123456", true, "2023-01-01T00:00:00Z"},
+		{"v0.0.0-20231301000000-abcdef123456", true, ""},
+		{"v1.2.3", false, ""},
+		{"v1.2.3-beta", false, ""},
+		{"v1.2.0-20230101000000-abcdef123456", false, ""},
+		{"v1.2.4-1.20230101000000-abcdef123456", false, ""},
+		{"v0.0.0-2023010100000-abcdef123456", false, ""},
+		{"v0.0.0-20230101000000-", false, ""},
+		{"v0.0.0-20230101000000", false, ""},
+		{"v0.0.0.0-20230101000000-abcdef123456", false, ""},
+	}
+
+	for _, tc := range tests {
+		v := MustParse(tc.version)
+		tt.AssertEqual(t, tc.pseudo, v.IsPseudoVersion())
+
+		ts, ok := v.PseudoVersionTime()
+		tt.AssertEqual(t, tc.expected != "", ok)
+		if ok {
+			tt.AssertEqual(t, tc.expected, ts.Format(time.RFC3339))
+		}
+	}
+}
+
+func TestCore(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"v1.2.3-rc.1+build", "v1.2.3"},
+		{"1.2.3-rc.1", "1.2.3"},
+		{"1.2.3+build", "1.2.3"},
+		{"1.2.3", "1.2.3"},
+		{"v1.2", "v1.2"},
+		{"1.2.3.4-beta", "1.2.3.4"},
+	}
+
+	for _, tc := range tests {
+		v := MustParse(tc.version)
+		core := v.Core()
+		tt.AssertEqual(t, tc.expected, core.Original())
+		tt.AssertEqual(t, tc.version, v.Original())
+		tt.AssertFalse(t, core.IsPrerelease())
+		tt.AssertEqual(t, "", core.Metadata())
+	}
+}
+
+func TestTruncatePrerelease(t *testing.T) {
+	tests := []struct {
+		version  string
+		n        int
+		expected string
+	}{
+		{"1.2.0-beta.3.build.7", 1, "1.2.0-beta"},
+		{"1.2.0-beta.3.build.7", 2, "1.2.0-beta.3"},
+		{"1.2.0-beta.3.build.7", 4, "1.2.0-beta.3.build.7"},
+		{"1.2.0-beta.3.build.7", 10, "1.2.0-beta.3.build.7"},
+		{"v1.2.0-beta.3+meta", 1, "v1.2.0-beta+meta"},
+		{"1.2.0-beta.3", 0, "1.2.0"},
+		{"1.2.0-beta.3", -1, "1.2.0"},
+		{"1.2.0", 1, "1.2.0"},
+	}
+
+	for _, tc := range tests {
+		v := MustParse(tc.version)
+		got := v.TruncatePrerelease(tc.n)
+		tt.AssertEqual(t, tc.expected, got.Original())
+		tt.AssertEqual(t, tc.version, v.Original())
+	}
+}
+
+func TestOrderedKey(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected [4]uint64
+	}{
+		{"1.2.3", [4]uint64{1, 2, 3, math.MaxUint64}},
+		{"v1.2+build", [4]uint64{1, 2, 0, math.MaxUint64}},
+		{"1.2.3-7.beta", [4]uint64{1, 2, 3, 7}},
+		{"1.2.3-10000000000000000000", [4]uint64{1, 2, 3, math.MaxInt64}},
+		{"1.2.3-a", [4]uint64{1, 2, 3, 1<<63 | 'a'<<48}},
+	}
+
+	for _, tc := range tests {
+		tt.AssertEqual(t, tc.expected, MustParse(tc.version).OrderedKey())
+	}
+
+	// Keys are ordered like the versions.
+	ordered := []string{
+		"1.0.0-0",
+		"1.0.0-2",
+		"1.0.0-10",
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha1",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1-0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0",
+	}
+
+	less := func(a, b [4]uint64) bool {
+		for i := range a {
+			if a[i] != b[i] {
+				return a[i] < b[i]
+			}
+		}
+		return false
+	}
+
+	for i := 1; i < len(ordered); i++ {
+		prev, cur := MustParse(ordered[i-1]), MustParse(ordered[i])
+		if less(cur.OrderedKey(), prev.OrderedKey()) {
+			t.Errorf("key of %s is less than the key of %s", cur, prev)
+		}
+	}
+
+	keys := map[[4]uint64]bool{MustParse("1.2.3").OrderedKey(): true}
+	tt.AssertTrue(t, keys[MustParse("v1.2.3+build").OrderedKey()])
+}
+
+func TestTrack(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2.3", "1.2"},
+		{"v1.2.4+build.5", "1.2"},
+		{"1.2.0-beta.3", "1.2-beta"},
+		{"1.2.1-beta", "1.2-beta"},
+		{"1.2.0-rc.1+build", "1.2-rc"},
+		{"1.2.0-1", "1.2-pre"},
+		{"1.2.0-0.3.7", "1.2-pre"},
+		{"1", "1.0"},
+		{"1.2.3.4-alpha", "1.2-alpha"},
+	}
+
+	for _, tc := range tests {
+		tt.AssertEqual(t, tc.expected, MustParse(tc.version).Track())
+	}
+}
+
+func TestPrereleaseNumber(t *testing.T) {
+	tests := []struct {
+		version string
+		parts   []string
+		number  uint64
+		ok      bool
+	}{
+		{"1.2.0-beta.42", []string{"beta", "42"}, 42, true},
+		{"1.2.0-42", []string{"42"}, 42, true},
+		{"1.2.0-rc.1+build.7", []string{"rc", "1"}, 1, true},
+		{"1.2.0-rc.0", []string{"rc", "0"}, 0, true},
+		{"1.2.0-42.beta", []string{"42", "beta"}, 0, false},
+		{"1.2.0-rc1", []string{"rc1"}, 0, false},
+		{"1.2.0+42", nil, 0, false},
+		{"1.2.0", nil, 0, false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v := MustParse(tc.version)
+			tt.AssertEqual(t, tc.parts, v.PrereleaseParts())
+
+			n, ok := v.PrereleaseNumber()
+			tt.AssertEqual(t, tc.number, n)
+			tt.AssertEqual(t, tc.ok, ok)
+		})
+	}
+}
+
+func TestMap(t *testing.T) {
+	m := MustParse("v1.2.3-beta.1+build.5").Map()
+	tt.AssertEqual(t, map[string]interface{}{
+		"Major":      uint64(1),
+		"Minor":      uint64(2),
+		"Patch":      uint64(3),
+		"Prerelease": "beta.1",
+		"Metadata":   "build.5",
+		"Original":   "v1.2.3-beta.1+build.5",
+		"String":     "1.2.3-beta.1+build.5",
+	}, m)
+
+	tmpl := template.Must(template.New("").Parse("{{.Major}}.{{.Minor}}{{if .Prerelease}} ({{.Prerelease}}){{end}}"))
+
+	buf := &strings.Builder{}
+	tt.AssertIsNotError(t, tmpl.Execute(buf, MustParse("1.2-rc.1").Map()))
+	tt.AssertEqual(t, "1.2 (rc.1)", buf.String())
+}
+
+func TestComponents(t *testing.T) {
+	c := MustParse("v1.2.3-beta.1+build.5").Components()
+	tt.AssertEqual(t, Components{Major: 1, Minor: 2, Patch: 3, Prerelease: "beta.1", Build: "build.5"}, c)
+
+	b, err := json.Marshal(c)
+	tt.AssertIsNotError(t, err)
+	tt.AssertEqual(t, `{"major":1,"minor":2,"patch":3,"prerelease":"beta.1","build":"build.5"}`, string(b))
+
+	tt.AssertEqual(t, Components{Major: 1}, MustParse("1").Components())
+	tt.AssertEqual(t, Components{Major: 1, Minor: 2, Patch: 3}, MustParse("1.2.3.4").Components())
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		version  string
+		layout   string
+		expected string
+	}{
+		{"1.2.3", "v%M.%m", "v1.2"},
+		{"v1.2.3-beta.1+build.5", "%M.%m.%p-%P+%B", "1.2.3-beta.1+build.5"},
+		{"1.2.3", "%M.%m.%p-%P", "1.2.3-"},
+		{"1", "%M.%m.%p", "1.0.0"},
+		{"1.2.3+build.5", "%M.%m.%p_%B", "1.2.3_build.5"},
+		{"1.2.3", "100%% %M", "100% 1"},
+		{"1.2.3", "%x %M%", "%x 1%"},
+		{"1.2.3", "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.layout, func(t *testing.T) {
+			tt.AssertEqual(t, tc.expected, MustParse(tc.version).Format(tc.layout))
+		})
+	}
+}
+
+func TestDebug(t *testing.T) {
+	tt.AssertEqual(t,
+		`Version{parts:[1 2 3], pre:"beta.1", metadata:"build.5", original:"v1.2.3-beta.1+build.5", prefix:"v"}`,
+		MustParse("v1.2.3-beta.1+build.5").Debug())
+	tt.AssertEqual(t,
+		`Version{parts:[1 2], pre:"", metadata:"", original:"1.2", prefix:""}`,
+		MustParse("1.2").Debug())
+	tt.AssertEqual(t,
+		`Version{parts:[], pre:"", metadata:"", original:"", prefix:""}`,
+		NewVersionByParts().Debug())
+}
+
+func TestSignificantParts(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected int
+	}{
+		{"1.2.0.0", 2},
+		{"1.2.3", 3},
+		{"1.0.0", 1},
+		{"0.0.1", 3},
+		{"0.0.0", 1},
+		{"0", 1},
+		{"1.2.0-beta", 2},
+		{"1.0.0.4", 4},
+	}
+
+	for _, tc := range tests {
+		if a := MustParse(tc.version).SignificantParts(); a != tc.expected {
+			t.Errorf("SignificantParts of %q expected %d but got %d", tc.version, tc.expected, a)
+		}
+	}
+
+	tt.AssertEqual(t, 1, NewVersionByParts().SignificantParts())
 }
 
 func TestCoerceString(t *testing.T) {
@@ -263,6 +1082,211 @@ func TestCompare(t *testing.T) {
 	}
 }
 
+func TestCompareNil(t *testing.T) {
+	var null *Version
+
+	tests := []struct {
+		version  string
+		expected int
+	}{
+		{"1.2.3", 1},
+		{"0.0.1", 1},
+		{"0.0.0", 0},
+		{"0", 0},
+		{"0.0.0+meta", 0},
+		{"0.0.0-alpha", -1},
+	}
+
+	for _, tc := range tests {
+		v := MustParse(tc.version)
+
+		if a := v.Compare(nil); a != tc.expected {
+			t.Errorf("%q.Compare(nil) expected %d but got %d", tc.version, tc.expected, a)
+		}
+		if a := null.Compare(v); a != -tc.expected {
+			t.Errorf("nil.Compare(%q) expected %d but got %d", tc.version, -tc.expected, a)
+		}
+
+		tt.AssertEqual(t, tc.expected == 0, v.Equal(nil))
+		tt.AssertEqual(t, tc.expected < 0, v.LessThan(nil))
+		tt.AssertEqual(t, tc.expected > 0, v.GreaterThan(nil))
+	}
+
+	tt.AssertEqual(t, 0, null.Compare(nil))
+}
+
+func TestCompareWithChannelOrder(t *testing.T) {
+	order := []string{"snapshot", "alpha", "beta", "rc"}
+
+	tests := []struct {
+		v1       string
+		v2       string
+		expected int
+	}{
+		{"1.0.0-snapshot.5", "1.0.0-alpha.1", -1},
+		{"1.0.0-rc.1", "1.0.0-beta.9", 1},
+		{"1.0.0-beta.2", "1.0.0-beta.10", -1},
+		{"1.0.0-snapshot", "1.0.0", -1},
+		{"1.0.0", "1.0.0-rc.1", 1},
+		{"1.0.1-snapshot", "1.0.0-rc.1", 1},
+		{"1.0.0-rc.1", "1.0.0-rc.1+build", 0},
+		// channels missing in order rank after the listed ones
+		{"1.0.0-dev", "1.0.0-alpha", 1},
+		{"1.0.0-dev", "1.0.0-snapshot", 1},
+		{"1.0.0-dev", "1.0.0-rc.1", 1},
+		{"1.0.0-1", "1.0.0-alpha", 1},
+		// and are ordered among themselves like Compare does
+		{"1.0.0-1", "1.0.0-dev", -1},
+		{"1.0.0-dev.2", "1.0.0-nightly", -1},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.v1+" "+tc.v2, func(t *testing.T) {
+			v1, v2 := MustParse(tc.v1), MustParse(tc.v2)
+			tt.AssertEqual(t, tc.expected, v1.CompareWithChannelOrder(v2, order))
+			tt.AssertEqual(t, -tc.expected, v2.CompareWithChannelOrder(v1, order))
+		})
+	}
+
+	// without an order it is the same as Compare
+	v1, v2 := MustParse("1.0.0-snapshot"), MustParse("1.0.0-alpha")
+	tt.AssertEqual(t, v1.Compare(v2), v1.CompareWithChannelOrder(v2, nil))
+
+	t.Run("transitive", func(t *testing.T) {
+		vs := []*Version{
+			MustParse("1.0.0-1"), MustParse("1.0.0-alpha"), MustParse("1.0.0-beta.2"),
+			MustParse("1.0.0-dev"), MustParse("1.0.0-rc.1"), MustParse("1.0.0-snapshot"),
+			MustParse("1.0.0-zeta"), MustParse("1.0.0"),
+		}
+
+		for _, a := range vs {
+			for _, b := range vs {
+				for _, c := range vs {
+					ab, bc := a.CompareWithChannelOrder(b, order), b.CompareWithChannelOrder(c, order)
+					if ab == bc && ab != 0 && a.CompareWithChannelOrder(c, order) != ab {
+						t.Errorf("%s, %s and %s are not ordered transitively", a, b, c)
+					}
+				}
+			}
+		}
+	})
+}
+
+func TestCompareParts(t *testing.T) {
+	tests := []struct {
+		a, b     []uint64
+		expected int
+	}{
+		{[]uint64{1, 2, 3}, []uint64{1, 5, 1}, -1},
+		{[]uint64{2, 2, 3}, []uint64{1, 5, 1}, 1},
+		{[]uint64{1, 2}, []uint64{1, 2, 0}, 0},
+		{[]uint64{1, 2}, []uint64{1, 2, 0, 1}, -1},
+		{[]uint64{1, 3}, []uint64{1, 2, 9}, 1},
+		{nil, []uint64{0}, 0},
+		{nil, nil, 0},
+		{[]uint64{18446744073709551615}, []uint64{0}, 1},
+	}
+
+	for _, tc := range tests {
+		if a := CompareParts(tc.a, tc.b); a != tc.expected {
+			t.Errorf("CompareParts(%v, %v) expected %d but got %d", tc.a, tc.b, tc.expected, a)
+		}
+		if b := CompareParts(tc.b, tc.a); b != -tc.expected {
+			t.Errorf("CompareParts(%v, %v) expected %d but got %d", tc.b, tc.a, -tc.expected, b)
+		}
+	}
+}
+
+func TestCompareTotal(t *testing.T) {
+	tests := []struct {
+		v1       string
+		v2       string
+		expected int
+	}{
+		{"1.2.3", "1.5.1", -1},
+		{"1.0.0-alpha", "1.0.0", -1},
+		{"1.0.0+a", "1.0.0+b", -1},
+		{"1.0.0", "1.0.0+a", -1},
+		{"1.0.0-rc+b", "1.0.0+a", -1},
+		{"1.0", "1.0.0", -1},
+		{"1.0+a", "1.0.0", 1},
+		{"1.0.0+a", "1.0.0+a", 0},
+		{"v1.0.0+a", "1.0.0+a", 0},
+	}
+
+	for _, tc := range tests {
+		v1, v2 := MustParse(tc.v1), MustParse(tc.v2)
+
+		if a := v1.CompareTotal(v2); a != tc.expected {
+			t.Errorf("CompareTotal of %q and %q failed. Expected %d, got %d", tc.v1, tc.v2, tc.expected, a)
+		}
+		if b := v2.CompareTotal(v1); b != -tc.expected {
+			t.Errorf("CompareTotal of %q and %q failed. Expected %d, got %d", tc.v2, tc.v1, -tc.expected, b)
+		}
+	}
+}
+
+func TestCompareCore(t *testing.T) {
+	tests := []struct {
+		v1       string
+		v2       string
+		expected int
+	}{
+		{"1.2.3-alpha", "1.2.3", 0},
+		{"1.2.3-alpha", "1.2.3-beta", 0},
+		{"1.2.3+a", "v1.2.3+b", 0},
+		{"1.2", "1.2.0-rc.1", 0},
+		{"1.2.3.0", "1.2.3", 0},
+		{"1.2.3", "1.2.4-alpha", -1},
+		{"1.2.3.4-alpha", "1.2.3", 1},
+		{"2.0.0-alpha", "1.9.9", 1},
+	}
+
+	for _, tc := range tests {
+		v1, v2 := MustParse(tc.v1), MustParse(tc.v2)
+
+		if a := v1.CompareCore(v2); a != tc.expected {
+			t.Errorf("CompareCore of %q and %q failed. Expected %d, got %d", tc.v1, tc.v2, tc.expected, a)
+		}
+		if b := v2.CompareCore(v1); b != -tc.expected {
+			t.Errorf("CompareCore of %q and %q failed. Expected %d, got %d", tc.v2, tc.v1, -tc.expected, b)
+		}
+	}
+}
+
+func TestCompareWithMask(t *testing.T) {
+	ignoreBuild := []bool{true, true, true, false}
+
+	tests := []struct {
+		v1       string
+		v2       string
+		mask     []bool
+		expected int
+	}{
+		{"1.2.3.7", "1.2.3.2", ignoreBuild, 0},
+		{"1.2.3.7", "1.2.3", ignoreBuild, 0},
+		{"1.2.4.1", "1.2.3.9", ignoreBuild, 1},
+		{"1.2.3.7-alpha", "1.2.3.2", ignoreBuild, -1},
+		{"1.2.3.7-beta", "1.2.3.2-alpha", ignoreBuild, 1},
+		{"1.2.3.7", "1.2.3.2", nil, 1},
+		{"1.2.3.7", "1.2.3.2", []bool{true, true}, 1},
+		{"1.5.3", "1.2.3", []bool{true, false}, 0},
+		{"2.5.3", "1.9.3", []bool{false, true}, -1},
+		{"1.2.3+a", "1.2.3+b", nil, 0},
+	}
+
+	for _, tc := range tests {
+		v1, v2 := MustParse(tc.v1), MustParse(tc.v2)
+
+		if a := v1.CompareWithMask(v2, tc.mask); a != tc.expected {
+			t.Errorf("CompareWithMask of %q and %q with %v failed. Expected %d, got %d", tc.v1, tc.v2, tc.mask, tc.expected, a)
+		}
+		if b := v2.CompareWithMask(v1, tc.mask); b != -tc.expected {
+			t.Errorf("CompareWithMask of %q and %q with %v failed. Expected %d, got %d", tc.v2, tc.v1, tc.mask, -tc.expected, b)
+		}
+	}
+}
+
 func TestLessThan(t *testing.T) {
 	tests := []struct {
 		v1    string
@@ -306,80 +1330,378 @@ func TestLessThan(t *testing.T) {
 	}
 }
 
-func TestGreaterThan(t *testing.T) {
+func TestGreaterThan(t *testing.T) {
+	tests := []struct {
+		v1       string
+		v2       string
+		expected bool
+	}{
+		{"1.2.3", "1.5.1", false},
+		{"2.2.3", "1.5.1", true},
+		{"3.2-beta", "3.2-beta", false},
+		{"3.2.0-beta.1", "3.2.0-beta.5", false},
+		{"3.2-beta.4", "3.2-beta.2", true},
+		{"7.43.0-SNAPSHOT.99", "7.43.0-SNAPSHOT.103", false},
+		{"7.43.0-SNAPSHOT.FOO", "7.43.0-SNAPSHOT.103", true},
+		{"7.43.0-SNAPSHOT.99", "7.43.0-SNAPSHOT.BAR", false},
+	}
+
+	for _, tc := range tests {
+		v1, err := NewVersion(tc.v1)
+		if err != nil {
+			t.Errorf("Error parsing version: %s", err)
+		}
+
+		v2, err := NewVersion(tc.v2)
+		if err != nil {
+			t.Errorf("Error parsing version: %s", err)
+		}
+
+		a := v1.GreaterThan(v2)
+		e := tc.expected
+		if a != e {
+			t.Errorf(
+				"Comparison of '%s' and '%s' failed. Expected '%t', got '%t'",
+				tc.v1, tc.v2, e, a,
+			)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	tests := []struct {
+		v1       string
+		v2       string
+		expected bool
+	}{
+		{"1", "1.0", true},
+		{"1.0", "1.0.0", true},
+		{"1.0", "1.0.1", false},
+		{"1.2.3", "1.5.1", false},
+		{"2.2.3", "1.5.1", false},
+		{"3.2-beta", "3.2-beta", true},
+		{"3.2-beta+foo", "3.2-beta+bar", true},
+		{"1.0", "1.0.0+foo", true},
+		{"1.0+foo", "1.0.0+bar", true},
+	}
+
+	for _, tc := range tests {
+		v1, err := NewVersion(tc.v1)
+		if err != nil {
+			t.Errorf("Error parsing version: %s", err)
+		}
+
+		v2, err := NewVersion(tc.v2)
+		if err != nil {
+			t.Errorf("Error parsing version: %s", err)
+		}
+
+		a := v1.Equal(v2)
+		e := tc.expected
+		if a != e {
+			t.Errorf(
+				"Comparison of '%s' and '%s' failed. Expected '%t', got '%t'",
+				tc.v1, tc.v2, e, a,
+			)
+		}
+	}
+}
+
+func TestDelta(t *testing.T) {
+	tests := []struct {
+		v1, v2              string
+		major, minor, patch int64
+	}{
+		{"1.5.0", "1.2.7", 0, 3, -7},
+		{"1.2.7", "1.5.0", 0, -3, 7},
+		{"3", "1.2.3", 2, -2, -3},
+		{"1.2.3-beta", "1.2.3", 0, 0, 0},
+		{"v2.0.0.9", "1.9", 1, -9, 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.v1+" "+tc.v2, func(t *testing.T) {
+			major, minor, patch := MustParse(tc.v1).Delta(MustParse(tc.v2))
+			tt.AssertEqual(t, tc.major, major)
+			tt.AssertEqual(t, tc.minor, minor)
+			tt.AssertEqual(t, tc.patch, patch)
+		})
+	}
+}
+
+func TestRequiredBump(t *testing.T) {
+	tests := []struct {
+		current  string
+		minimum  string
+		expected string
+	}{
+		{"1.2.3", "1.5.0", "minor"},
+		{"1.2.3", "2.0.0", "major"},
+		{"1.2.3", "1.2.4", "patch"},
+		{"1.2.3", "1.2.3.1", "patch"},
+		{"1.2.3-rc.1", "1.2.3", "patch"},
+		{"1.2.3", "1.2.3", ""},
+		{"1.5.0", "1.2.3", ""},
+		{"1.2.3+build", "1.2.3", ""},
+		{"v0.9", "1", "major"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.current+" "+tc.minimum, func(t *testing.T) {
+			tt.AssertEqual(t, tc.expected, RequiredBump(MustParse(tc.current), MustParse(tc.minimum)))
+		})
+	}
+}
+
+func TestReleaseLabel(t *testing.T) {
+	tests := []struct {
+		from     string
+		to       string
+		expected string
+	}{
+		{"1.2.3", "2.0.0", "Major release"},
+		{"1.2.3", "1.3.0", "Minor release"},
+		{"1.2.3", "1.2.4", "Patch release"},
+		{"1.2.3", "1.2.3.1", "Patch release"},
+		{"1.2", "1.2.1", "Patch release"},
+		{"1.2.3-rc.1", "1.2.3", "Patch release"},
+		{"1.2.3", "2.0.0-rc.1", "Prerelease"},
+		{"1.2.3-rc.1", "1.2.3-rc.2", "Prerelease"},
+		{"1.2.3", "1.2.2", "Downgrade"},
+		{"2.0.0", "1.9.9", "Downgrade"},
+		{"1.2.3", "1.2.3-rc.1", "Downgrade"},
+		{"1.2.3", "1.2.3+meta", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.from+"->"+tc.to, func(t *testing.T) {
+			tt.AssertEqual(t, tc.expected, ReleaseLabel(MustParse(tc.from), MustParse(tc.to)))
+		})
+	}
+}
+
+func TestIsUpgradeFrom(t *testing.T) {
+	tests := []struct {
+		from      string
+		to        string
+		upgrade   bool
+		downgrade bool
+	}{
+		{"1.2.3", "1.2.4", true, false},
+		{"1.2.4", "1.2.3", false, true},
+		{"1.2.3-rc.1", "1.2.3", true, false},
+		{"1.2.3", "1.2.3-rc.1", false, true},
+		{"1.2.3-rc.1", "1.2.3-rc.2", true, false},
+		{"1.2.3+build.1", "1.2.3+build.2", false, false},
+		{"1.2", "1.2.0", false, false},
+	}
+
+	for _, tc := range tests {
+		from, to := MustParse(tc.from), MustParse(tc.to)
+		if a := to.IsUpgradeFrom(from); a != tc.upgrade {
+			t.Errorf("Expected %q IsUpgradeFrom %q to be %t", tc.to, tc.from, tc.upgrade)
+		}
+		if a := to.IsDowngradeFrom(from); a != tc.downgrade {
+			t.Errorf("Expected %q IsDowngradeFrom %q to be %t", tc.to, tc.from, tc.downgrade)
+		}
+	}
+}
+
+func TestIsPatchSuccessorOf(t *testing.T) {
+	tests := []struct {
+		from     string
+		to       string
+		expected bool
+	}{
+		{"1.2.3", "1.2.4", true},
+		{"1.2.3", "v1.2.10+build", true},
+		{"1.2.3-rc.1", "1.2.4", true},
+		{"1.2", "1.2.1", true},
+		{"1.2.3", "1.2.3", false},
+		{"1.2.4", "1.2.3", false},
+		{"1.2.3", "1.3.0", false},
+		{"1.2.3", "1.3.4", false},
+		{"1.2.3", "2.2.4", false},
+		{"1.2.3", "1.2.4-rc.1", false},
+		{"1.2.3-rc.1", "1.2.3", false},
+		{"1.2.3", "1.2.3.1", false},
+	}
+
+	for _, tc := range tests {
+		from, to := MustParse(tc.from), MustParse(tc.to)
+		if a := to.IsPatchSuccessorOf(from); a != tc.expected {
+			t.Errorf("Expected %q IsPatchSuccessorOf %q to be %t", tc.to, tc.from, tc.expected)
+		}
+	}
+}
+
+func TestAtLeastAtMostBetween(t *testing.T) {
+	tests := []struct {
+		have, low, high string
+		atLeast         bool
+		atMost          bool
+	}{
+		{"1.2.0", "1.2.0", "1.2.0", true, true},
+		{"1.2.1", "1.2.0", "1.3.0", true, true},
+		{"v1.2", "1.2.0", "1.2.0", true, true},
+		{"1.1.9", "1.2.0", "1.3.0", false, true},
+		{"1.3.1", "1.2.0", "1.3.0", true, false},
+		{"1.2.0-rc.1", "1.2.0", "1.3.0", false, true},
+		{"1.3.0-rc.1", "1.2.0", "1.3.0", true, true},
+		{"1.2.0+meta", "1.2.0", "1.2.0", true, true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.have, func(t *testing.T) {
+			a, err := AtLeast(tc.have, tc.low)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.atLeast, a)
+
+			a, err = AtMost(tc.have, tc.high)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.atMost, a)
+
+			a, err = Between(tc.have, tc.low, tc.high)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.atLeast && tc.atMost, a)
+		})
+	}
+
+	_, err := AtLeast("foo", "1.2.3")
+	tt.AssertEqual(t, ErrInvalidCharacters, err)
+	_, err = AtMost("1.2.3", "")
+	tt.AssertEqual(t, ErrEmptyString, err)
+	_, err = Between("1.2.3", "1.0.0", "bar")
+	tt.AssertEqual(t, ErrInvalidCharacters, err)
+	_, err = Between("1.0.0", "1.2.3", "bar")
+	tt.AssertEqual(t, ErrInvalidCharacters, err)
+	_, err = Between("1.2.3", "bar", "2.0.0")
+	tt.AssertEqual(t, ErrInvalidCharacters, err)
+}
+
+func TestRequireAtLeast(t *testing.T) {
+	tt.AssertIsNotError(t, RequireAtLeast("1.2.0", "1.2.0"))
+	tt.AssertIsNotError(t, RequireAtLeast("v1.3", "1.2.0"))
+
+	err := RequireAtLeast("1.1.0", "1.2.0")
+	tt.AssertIsError(t, err)
+	tt.AssertEqual(t, "current version 1.1.0 is below required minimum 1.2.0", err.Error())
+
+	err = RequireAtLeast("1.2.0-rc.1", "1.2.0")
+	tt.AssertIsError(t, err)
+	tt.AssertEqual(t, "current version 1.2.0-rc.1 is below required minimum 1.2.0", err.Error())
+
+	err = RequireAtLeast("foo", "1.2.0")
+	tt.AssertEqual(t, `invalid version "foo": invalid characters in version`, err.Error())
+	tt.AssertTrue(t, errors.Is(err, ErrInvalidCharacters))
+
+	err = RequireAtLeast("1.2.0", "")
+	tt.AssertTrue(t, errors.Is(err, ErrEmptyString))
+}
+
+func TestEqualFold(t *testing.T) {
 	tests := []struct {
 		v1       string
 		v2       string
 		expected bool
 	}{
-		{"1.2.3", "1.5.1", false},
-		{"2.2.3", "1.5.1", true},
-		{"3.2-beta", "3.2-beta", false},
-		{"3.2.0-beta.1", "3.2.0-beta.5", false},
-		{"3.2-beta.4", "3.2-beta.2", true},
-		{"7.43.0-SNAPSHOT.99", "7.43.0-SNAPSHOT.103", false},
-		{"7.43.0-SNAPSHOT.FOO", "7.43.0-SNAPSHOT.103", true},
-		{"7.43.0-SNAPSHOT.99", "7.43.0-SNAPSHOT.BAR", false},
+		{"1.2.0-RC.1", "1.2.0-rc.1", true},
+		{"v1.2-Beta+META", "1.2.0-beta+meta", true},
+		{"1.2.0-rc.1+a", "1.2.0-rc.1+b", true},
+		{"1.2.0", "1.2.0", true},
+		{"1.2.0-rc.1", "1.2.0-rc.2", false},
+		{"1.2.0-rc.1", "1.2.0", false},
+		{"1.2.0-RC.1", "1.2.1-rc.1", false},
 	}
 
 	for _, tc := range tests {
-		v1, err := NewVersion(tc.v1)
-		if err != nil {
-			t.Errorf("Error parsing version: %s", err)
-		}
+		t.Run(tc.v1+" "+tc.v2, func(t *testing.T) {
+			v1, v2 := MustParse(tc.v1), MustParse(tc.v2)
+			tt.AssertEqual(t, tc.expected, v1.EqualFold(v2))
+			tt.AssertEqual(t, tc.expected, v2.EqualFold(v1))
+		})
+	}
 
-		v2, err := NewVersion(tc.v2)
-		if err != nil {
-			t.Errorf("Error parsing version: %s", err)
-		}
+	// strict comparison is still case-sensitive
+	tt.AssertFalse(t, MustParse("1.2.0-RC.1").Equal(MustParse("1.2.0-rc.1")))
+}
 
-		a := v1.GreaterThan(v2)
-		e := tc.expected
-		if a != e {
-			t.Errorf(
-				"Comparison of '%s' and '%s' failed. Expected '%t', got '%t'",
-				tc.v1, tc.v2, e, a,
-			)
-		}
+func TestEqualIgnoringFormat(t *testing.T) {
+	tests := []struct {
+		v1       string
+		v2       string
+		expected bool
+	}{
+		{"1.2.3", "1.2.3", true},
+		{"v1.2", "1.2.0", true},
+		{"1.2.3.0", "1.2.3", true},
+		{"1.2.3-beta+meta", "1.2.3", true},
+		{"1.2.3", "1.2.4", false},
+		{"1.2.3.1", "1.2.3", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.v1+" "+tc.v2, func(t *testing.T) {
+			v1, v2 := MustParse(tc.v1), MustParse(tc.v2)
+			tt.AssertEqual(t, tc.expected, v1.EqualIgnoringFormat(v2))
+			tt.AssertEqual(t, tc.expected, v2.EqualIgnoringFormat(v1))
+		})
 	}
+
+	// versions from zero padded sources can only be built from their parts
+	padded := &Version{parts: []uint64{1, 2, 3}, original: "01.02.03"}
+	tt.AssertTrue(t, padded.EqualIgnoringFormat(MustParse("1.2.3")))
 }
 
-func TestEqual(t *testing.T) {
+func TestLooselyEqual(t *testing.T) {
 	tests := []struct {
 		v1       string
 		v2       string
 		expected bool
 	}{
-		{"1", "1.0", true},
-		{"1.0", "1.0.0", true},
-		{"1.0", "1.0.1", false},
-		{"1.2.3", "1.5.1", false},
-		{"2.2.3", "1.5.1", false},
-		{"3.2-beta", "3.2-beta", true},
-		{"3.2-beta+foo", "3.2-beta+bar", true},
-		{"1.0", "1.0.0+foo", true},
-		{"1.0+foo", "1.0.0+bar", true},
+		{"1.2", "1.2.0.0", true},
+		{"1.2.3", "1.2.4", false},
+		{"1.2.3+a", "1.2.3+b", true},
+		{"1.2.3-beta", "1.2.3-beta.0", true},
+		{"1.2.3-beta", "1.2.3-beta.0.0", true},
+		{"1.2-beta.0+a", "v1.2.0-beta", true},
+		{"1.2.3-beta", "1.2.3-beta.1", false},
+		{"1.2.3-beta", "1.2.3-alpha", false},
+		{"1.2.3-beta", "1.2.3", false},
+		{"1.2.3-0", "1.2.3", false},
 	}
 
 	for _, tc := range tests {
-		v1, err := NewVersion(tc.v1)
-		if err != nil {
-			t.Errorf("Error parsing version: %s", err)
+		v1, v2 := MustParse(tc.v1), MustParse(tc.v2)
+		if a := v1.LooselyEqual(v2); a != tc.expected {
+			t.Errorf("LooselyEqual of %q and %q expected %t but got %t", tc.v1, tc.v2, tc.expected, a)
 		}
-
-		v2, err := NewVersion(tc.v2)
-		if err != nil {
-			t.Errorf("Error parsing version: %s", err)
+		if a := v2.LooselyEqual(v1); a != tc.expected {
+			t.Errorf("LooselyEqual of %q and %q expected %t but got %t", tc.v2, tc.v1, tc.expected, a)
 		}
+	}
+}
 
-		a := v1.Equal(v2)
-		e := tc.expected
-		if a != e {
-			t.Errorf(
-				"Comparison of '%s' and '%s' failed. Expected '%t', got '%t'",
-				tc.v1, tc.v2, e, a,
-			)
-		}
+func TestFillZeros(t *testing.T) {
+	tests := []struct {
+		v1       string
+		toParts  int
+		expected string
+	}{
+		{"1.2", 3, "1.2.0"},
+		{"1", 3, "1.0.0"},
+		{"v1.2-beta+meta", 3, "v1.2.0-beta+meta"},
+		{"1.2.3", 3, "1.2.3"},
+		{"1.2.3.4", 3, "1.2.3.4"},
+		{"1.2", 5, "1.2.0.0.0"},
+	}
+
+	for _, tc := range tests {
+		v1 := MustParse(tc.v1)
+		v2 := v1.FillZeros(tc.toParts)
+		tt.AssertEqual(t, tc.expected, v2.Original())
+		tt.AssertEqual(t, tc.v1, v1.Original())
+		tt.AssertTrue(t, v1.Equal(&v2))
 	}
 }
 
@@ -441,6 +1763,60 @@ func TestIncPart(t *testing.T) {
 
 }
 
+func TestIncPartChecked(t *testing.T) {
+	tests := []struct {
+		v1       string
+		part     int
+		expected string
+		err      error
+	}{
+		{"1.2.18446744073709551615", 3, "1.2.18446744073709551615", ErrPartOverflow},
+		{"1.2.18446744073709551615", 2, "1.3.0", nil},
+		{"1.2.18446744073709551615-beta", 3, "1.2.18446744073709551615", nil},
+		{"1.2.18446744073709551615", 4, "1.2.18446744073709551615.1", nil},
+		{"18446744073709551615", 1, "18446744073709551615", ErrPartOverflow},
+		{"18446744073709551615.1", 1, "18446744073709551615.1", ErrPartOverflow},
+		{"1.2.18446744073709551614", 3, "1.2.18446744073709551615", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%s-increase-%d", tc.v1, tc.part), func(t *testing.T) {
+			v2, err := MustParse(tc.v1).IncPartChecked(tc.part)
+			tt.AssertEqual(t, tc.err, err)
+			tt.AssertEqual(t, tc.expected, v2.String())
+		})
+	}
+}
+
+func TestWouldBreakOnInc(t *testing.T) {
+	tests := []struct {
+		v1       string
+		part     int
+		expected bool
+	}{
+		{"1.2.3", 1, true},
+		{"1.2.3", 2, false},
+		{"1.2.3", 3, false},
+		{"1.2.3", 4, false},
+		{"1.2.3-beta", 1, true},
+		{"1.2.3-beta", 3, false},
+		{"1", 1, true},
+		{"1-beta", 1, false},
+	}
+
+	for _, tc := range tests {
+		v := MustParse(tc.v1)
+		if a := v.WouldBreakOnInc(tc.part); a != tc.expected {
+			t.Errorf("WouldBreakOnInc(%d) of %q expected %t but got %t", tc.part, tc.v1, tc.expected, a)
+		}
+
+		next := v.IncPart(tc.part)
+		if changed := next.Major() != v.Major(); changed != tc.expected {
+			t.Errorf("IncPart(%d) of %q changes major: %t, but WouldBreakOnInc is %t", tc.part, tc.v1, changed, tc.expected)
+		}
+	}
+}
+
 func TestInc(t *testing.T) {
 	tests := []struct {
 		v1               string
@@ -466,6 +1842,13 @@ func TestInc(t *testing.T) {
 		{"v1.2.4-beta+meta", "1.3.0", "minor", "v1.3.0"},
 		{"1.2.3-beta+meta", "2.0.0", "major", "2.0.0"},
 		{"v1.2.4-beta+meta", "2.0.0", "major", "v2.0.0"},
+		{"v1.2.3.4", "1.2.3.5", "last", "v1.2.3.5"},
+		{"v1.2.3.4+meta", "1.2.3.5", "last", "v1.2.3.5"},
+		{"v1.2.3.4-beta", "1.2.3.4", "last", "v1.2.3.4"},
+		{"v1.2.3.4", "1.2.4.0", "patch", "v1.2.4.0"},
+		{"v1.2.3.4", "1.3.0.0", "minor", "v1.3.0.0"},
+		{"v1.2.3.4", "2.0.0.0", "major", "v2.0.0.0"},
+		{"v1.2.3.4.5", "1.2.3.4.6", "last", "v1.2.3.4.6"},
 	}
 
 	for _, tc := range tests {
@@ -507,6 +1890,167 @@ func TestInc(t *testing.T) {
 	}
 }
 
+func TestIncKeepsPrefixAndParts(t *testing.T) {
+	v, err := NewVersionWithPrefixes("release-1.2.3.4", "release-")
+	tt.AssertIsNotError(t, err)
+
+	next := v.IncLast()
+	tt.AssertEqual(t, "release-1.2.3.5", next.Original())
+	tt.AssertEqual(t, "1.2.3.5", next.String())
+	tt.AssertEqual(t, 4, next.PartsNumber())
+
+	// incrementing again works from the derived version
+	next = next.IncLast()
+	tt.AssertEqual(t, "release-1.2.3.6", next.Original())
+	tt.AssertEqual(t, "release-1.2.3.4", v.Original())
+}
+
+func TestNextBoundary(t *testing.T) {
+	tests := []struct {
+		v1    string
+		major string
+		minor string
+		patch string
+	}{
+		{"1.2.3", "2.0.0", "1.3.0", "1.2.4"},
+		{"v1.2.3", "v2.0.0", "v1.3.0", "v1.2.4"},
+		{"1.2.3-beta+meta", "2.0.0", "1.3.0", "1.2.4"},
+		{"1", "2.0.0", "1.1.0", "1.0.1"},
+		{"1.2", "2.0.0", "1.3.0", "1.2.1"},
+		{"1.2.3.4", "2.0.0", "1.3.0", "1.2.4"},
+		{"0.0.0", "1.0.0", "0.1.0", "0.0.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.v1, func(t *testing.T) {
+			v := MustParse(tc.v1)
+			major, minor, patch := v.NextMajor(), v.NextMinor(), v.NextPatch()
+			tt.AssertEqual(t, tc.major, major.Original())
+			tt.AssertEqual(t, tc.minor, minor.Original())
+			tt.AssertEqual(t, tc.patch, patch.Original())
+			tt.AssertEqual(t, tc.patch, v.originalVPrefix()+patch.String())
+			tt.AssertEqual(t, tc.v1, v.Original())
+		})
+	}
+
+	t.Run("zero parts", func(t *testing.T) {
+		v := NewVersionByParts()
+		major, minor, patch := v.NextMajor(), v.NextMinor(), v.NextPatch()
+		tt.AssertEqual(t, "1.0.0", major.String())
+		tt.AssertEqual(t, "0.1.0", minor.String())
+		tt.AssertEqual(t, "0.0.1", patch.String())
+	})
+
+	t.Run("overflow", func(t *testing.T) {
+		// The increased part carries into the previous one.
+		v := NewVersionByParts(1, math.MaxUint64, math.MaxUint64)
+		major, minor, patch := v.NextMajor(), v.NextMinor(), v.NextPatch()
+		tt.AssertEqual(t, "2.0.0", major.String())
+		tt.AssertEqual(t, "2.0.0", minor.String())
+		tt.AssertEqual(t, "2.0.0", patch.String())
+
+		// Without a previous part to carry into the result saturates.
+		v = NewVersionByParts(math.MaxUint64, math.MaxUint64, math.MaxUint64)
+		max := "18446744073709551615.18446744073709551615.18446744073709551615"
+		major, minor, patch = v.NextMajor(), v.NextMinor(), v.NextPatch()
+		tt.AssertEqual(t, max, major.String())
+		tt.AssertEqual(t, max, minor.String())
+		tt.AssertEqual(t, max, patch.String())
+		tt.AssertFalse(t, patch.LessThan(v))
+
+		v = MustParse("v18446744073709551615.1.2")
+		major = v.NextMajor()
+		tt.AssertEqual(t, "v"+max, major.Original())
+		tt.AssertTrue(t, major.GreaterThan(v))
+	})
+}
+
+func TestBump(t *testing.T) {
+	tests := []struct {
+		v1       string
+		kind     string
+		expected string
+	}{
+		{"1.2.3", "major", "2.0.0"},
+		{"1.2.3", "minor", "1.3.0"},
+		{"1.2.3+meta", "patch", "1.2.4"},
+		{"1.2.3-beta", "patch", "1.2.3"},
+		{"1.2.3", "premajor", "2.0.0-0"},
+		{"1.2.3", "preminor", "1.3.0-0"},
+		{"1.2.3", "prepatch", "1.2.4-0"},
+		{"v1.2.3", "prepatch", "v1.2.4-0"},
+		{"1.2.3", "prerelease", "1.2.4-0"},
+		{"1.2.4-0", "prerelease", "1.2.4-1"},
+		{"1.2.3-beta.1+meta", "prerelease", "1.2.3-beta.2"},
+		{"1.2.3-beta", "prerelease", "1.2.3-beta.0"},
+		{"1.2.3-1.beta", "prerelease", "1.2.3-1.beta.0"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.v1+" "+tc.kind, func(t *testing.T) {
+			v := MustParse(tc.v1)
+			next, err := v.Bump(tc.kind)
+			tt.AssertIsNotError(t, err)
+			tt.AssertEqual(t, tc.expected, next.Original())
+			tt.AssertEqual(t, tc.v1, v.Original())
+		})
+	}
+
+	v := MustParse("1.2.3")
+	_, err := v.Bump("release")
+	tt.AssertIsError(t, err)
+
+	v = MustParse("1.2.3-beta.18446744073709551615")
+	_, err = v.Bump("prerelease")
+	tt.AssertEqual(t, ErrPartOverflow, err)
+
+	v = NewVersionByParts(math.MaxUint64, math.MaxUint64, math.MaxUint64)
+	for _, kind := range []string{"premajor", "preminor", "prepatch", "prerelease"} {
+		_, err = v.Bump(kind)
+		tt.AssertEqual(t, ErrPartOverflow, err)
+	}
+}
+
+func TestPrereleaseSequence(t *testing.T) {
+	v := MustParse("v1.2.0-beta.3+build.5")
+
+	seq := v.PrereleaseSequence("rc", 3)
+	tt.AssertEqual(t, 3, len(seq))
+	for i, expected := range []string{"v1.2.0-rc.1", "v1.2.0-rc.2", "v1.2.0-rc.3"} {
+		tt.AssertEqual(t, expected, seq[i].Original())
+	}
+	tt.AssertEqual(t, "v1.2.0-beta.3+build.5", v.Original())
+
+	tt.AssertIsNil(t, v.PrereleaseSequence("rc", 0))
+	tt.AssertIsNil(t, v.PrereleaseSequence("", 2))
+	tt.AssertIsNil(t, v.PrereleaseSequence("r_c", 2))
+	tt.AssertIsNil(t, v.PrereleaseSequence("rc.x", 2))
+}
+
+func TestPromoteIfPrereleaseAtLeast(t *testing.T) {
+	tests := []struct {
+		version  string
+		n        uint64
+		expected string
+	}{
+		{"1.2.0-rc.3", 3, "1.2.0"},
+		{"v1.2.0-rc.4+build.1", 3, "v1.2.0"},
+		{"1.2.0-rc.2", 3, "1.2.0-rc.2"},
+		{"1.2.0-rc", 0, "1.2.0-rc"},
+		{"1.2.0-7", 5, "1.2.0"},
+		{"1.2.0+build.9", 1, "1.2.0+build.9"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			v := MustParse(tc.version)
+			promoted := v.PromoteIfPrereleaseAtLeast(tc.n)
+			tt.AssertEqual(t, tc.expected, promoted.Original())
+			tt.AssertEqual(t, tc.version, v.Original())
+		})
+	}
+}
+
 func TestSetPrerelease(t *testing.T) {
 	tests := []struct {
 		v1                 string
@@ -647,6 +2191,24 @@ func TestJsonUnmarshal(t *testing.T) {
 	}
 }
 
+func TestUnmarshalError(t *testing.T) {
+	type config struct {
+		Version Version `json:"version"`
+	}
+
+	var c config
+	err := json.Unmarshal([]byte(`{"version": "foo bar"}`), &c)
+	tt.AssertIsError(t, err)
+	tt.AssertEqual(t, `invalid version "foo bar": invalid characters in version`, err.Error())
+	tt.AssertTrue(t, errors.Is(err, ErrInvalidCharacters))
+
+	v := &Version{}
+	err = v.UnmarshalText([]byte(""))
+	tt.AssertIsError(t, err)
+	tt.AssertEqual(t, `invalid version "": version string empty`, err.Error())
+	tt.AssertTrue(t, errors.Is(err, ErrEmptyString))
+}
+
 func TestTextMarshal(t *testing.T) {
 	sVer := "1.1.1"
 
@@ -667,6 +2229,20 @@ func TestTextMarshal(t *testing.T) {
 	}
 }
 
+func TestAppendText(t *testing.T) {
+	v1 := MustParse("v1.2.3-beta+build")
+	v2 := MustParse("2.0")
+
+	b := []byte("versions: ")
+	b, err := v1.AppendText(b)
+	tt.AssertIsNotError(t, err)
+	b = append(b, ',')
+	b, err = v2.AppendBinary(b)
+	tt.AssertIsNotError(t, err)
+
+	tt.AssertEqual(t, "versions: 1.2.3-beta+build,2.0", string(b))
+}
+
 func TestTextUnmarshal(t *testing.T) {
 	sVer := "1.1.1"
 	ver := &Version{}
@@ -737,6 +2313,111 @@ func TestValidatePrerelease(t *testing.T) {
 	}
 }
 
+func TestValidatePrereleaseDetailed(t *testing.T) {
+	tests := []struct {
+		pre      string
+		expected string
+	}{
+		{"foo", ""},
+		{"alpha.1", ""},
+		{"alpha.0-1", ""},
+		{"alpha.01", ErrSegmentStartsZero.Error()},
+		{"foo☃︎", "invalid character '☃' in prerelease"},
+		{"alpha.be ta", "invalid character ' ' in prerelease"},
+		{"rc_1", "invalid character '_' in prerelease"},
+	}
+
+	for _, tc := range tests {
+		err := ValidatePrereleaseDetailed(tc.pre)
+		if tc.expected == "" {
+			if err != nil {
+				t.Errorf("Unexpected error %q for prerelease %q", err, tc.pre)
+			}
+		} else if err == nil || err.Error() != tc.expected {
+			t.Errorf("Expected error %q for prerelease %q but got %v", tc.expected, tc.pre, err)
+		}
+
+		if (err == nil) != (validatePrerelease(tc.pre) == nil) {
+			t.Errorf("ValidatePrereleaseDetailed and validatePrerelease disagree on %q", tc.pre)
+		}
+	}
+}
+
+func TestValidatePrereleaseParts(t *testing.T) {
+	tests := []struct {
+		parts    []string
+		expected string
+		err      error
+	}{
+		{nil, "", nil},
+		{[]string{"beta", "1"}, "", nil},
+		{[]string{"alpha", "0", "x-y"}, "", nil},
+		{[]string{"rc", "01"}, `prerelease identifier 1 ("01"): version segment starts with 0`, ErrSegmentStartsZero},
+		{[]string{"rc", "", "1"}, `prerelease identifier 1 (""): invalid Prerelease string`, ErrInvalidPrerelease},
+		{[]string{"rc_1"}, `prerelease identifier 0 ("rc_1"): invalid Prerelease string`, ErrInvalidPrerelease},
+		{[]string{"beta.1"}, `prerelease identifier 0 ("beta.1"): invalid Prerelease string`, ErrInvalidPrerelease},
+	}
+
+	for _, tc := range tests {
+		err := ValidatePrereleaseParts(tc.parts)
+		if tc.err == nil {
+			if err != nil {
+				t.Errorf("Unexpected error %q for %q", err, tc.parts)
+			}
+			continue
+		}
+
+		if err == nil || err.Error() != tc.expected {
+			t.Errorf("Expected error %q for %q but got %v", tc.expected, tc.parts, err)
+		}
+		if !errors.Is(err, tc.err) {
+			t.Errorf("Expected error for %q to wrap %v", tc.parts, tc.err)
+		}
+	}
+}
+
+func TestCheckSemVer2(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2.3", ""},
+		{"0.0.0", ""},
+		{"1.2.3-alpha.1", ""},
+		{"1.2.3-alpha.-1", ""},
+		{"1.2.3+build.01", ""},
+		{"1.2.3-x.Y.0+metadata-with-hyphen", ""},
+		{"", "version string empty"},
+		{"1.2", "invalid Semantic Version: expected three version parts, got 2"},
+		{"1.2.3.4", "invalid Semantic Version: expected three version parts, got 4"},
+		{"v1.2.3", `invalid Semantic Version: version part 1 ("v1") is not a number`},
+		{"1..3", `invalid Semantic Version: version part 2 ("") is not a number`},
+		{"1.02.3", `invalid Semantic Version: version part 2 ("02") has a leading zero`},
+		{"1.2.18446744073709551616", `invalid Semantic Version: version part 3 ("18446744073709551616") is too large`},
+		{"1.2.3-", "invalid Semantic Version: prerelease identifier is empty"},
+		{"1.2.3-alpha..1", "invalid Semantic Version: prerelease identifier is empty"},
+		{"1.2.3-alpha.01", `invalid Semantic Version: prerelease identifier "01" has a leading zero`},
+		{"1.2.3-alpha_1", `invalid Semantic Version: prerelease identifier "alpha_1" contains invalid characters`},
+		{"1.2.3+", "invalid Semantic Version: metadata identifier is empty"},
+		{"1.2.3+a+b", `invalid Semantic Version: metadata identifier "a+b" contains invalid characters`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.version, func(t *testing.T) {
+			err := CheckSemVer2(tc.version)
+			if tc.expected == "" {
+				tt.AssertIsNotError(t, err)
+				return
+			}
+
+			tt.AssertIsError(t, err)
+			tt.AssertEqual(t, tc.expected, err.Error())
+		})
+	}
+
+	tt.AssertTrue(t, errors.Is(CheckSemVer2("1.2"), ErrInvalidSemVer))
+}
+
 func TestValidateMetadata(t *testing.T) {
 	tests := []struct {
 		meta     string
@@ -769,6 +2450,52 @@ func benchStrictNewVersion(v string, b *testing.B) {
 	}
 }
 
+func BenchmarkNewVersionCached(b *testing.B) {
+	defer ClearVersionCache()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = NewVersionCached("1.0.0-alpha.1+meta.data")
+	}
+}
+
+func benchMarshalVersions() []Version {
+	vs := make([]Version, 100)
+	for i := range vs {
+		vs[i] = *NewVersionByParts(1, uint64(i), 3)
+	}
+	return vs
+}
+
+func BenchmarkMarshalText(b *testing.B) {
+	vs := benchMarshalVersions()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var buf []byte
+		for _, v := range vs {
+			t, _ := v.MarshalText()
+			buf = append(buf, t...)
+		}
+	}
+}
+
+func BenchmarkAppendText(b *testing.B) {
+	vs := benchMarshalVersions()
+	buf := make([]byte, 0, 1024)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buf = buf[:0]
+		for _, v := range vs {
+			buf, _ = v.AppendText(buf)
+		}
+	}
+}
+
 func BenchmarkNewVersionSimple(b *testing.B) {
 	b.ReportAllocs()
 	b.ResetTimer()
@@ -816,3 +2543,23 @@ func BenchmarkStrictNewVersionMetaDash(b *testing.B) {
 	b.ResetTimer()
 	benchStrictNewVersion("1.0.0-alpha.1+meta.data", b)
 }
+
+func BenchmarkCompareParts(b *testing.B) {
+	v1, v2 := []uint64{1, 2, 3}, []uint64{1, 2, 4}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		CompareParts(v1, v2)
+	}
+}
+
+func BenchmarkNewVersionCompare(b *testing.B) {
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		v1, _ := NewVersion("1.2.3")
+		v2, _ := NewVersion("1.2.4")
+		v1.Compare(v2)
+	}
+}